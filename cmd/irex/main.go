@@ -1,18 +1,36 @@
 package main
 
 import (
+	"errors"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/dotenv-org/godotenvvault"
+	explainCmd "github.com/kwizyHQ/irex/internal/cli/common/explain"
+	exportCmd "github.com/kwizyHQ/irex/internal/cli/common/export"
 	formatCmd "github.com/kwizyHQ/irex/internal/cli/common/format"
+	importCmd "github.com/kwizyHQ/irex/internal/cli/common/import"
 	initcmd "github.com/kwizyHQ/irex/internal/cli/common/init"
 	validateCmd "github.com/kwizyHQ/irex/internal/cli/common/validate"
 	"github.com/kwizyHQ/irex/internal/cli/common/watch"
+	"github.com/kwizyHQ/irex/internal/tempdir"
 	"github.com/kwizyHQ/irex/lsp"
 	"github.com/spf13/cobra"
 )
 
+// cleanupOnSignal deletes the temp directory and exits on SIGINT/SIGTERM.
+func cleanupOnSignal() {
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+		<-c
+		tempdir.Cleanup()
+		os.Exit(0)
+	}()
+}
+
 func main() {
 	err := godotenvvault.Load()
 	if err != nil {
@@ -21,6 +39,7 @@ func main() {
 
 	// setup logging (moved to logging.go)
 	SetupLogging()
+	cleanupOnSignal()
 
 	var rootCmd = &cobra.Command{
 		Use:   "irex",
@@ -35,9 +54,16 @@ func main() {
 	rootCmd.AddCommand(watchCmd)
 	rootCmd.AddCommand(formatCmd.Run())
 	rootCmd.AddCommand(validateCmd.NewValidateCmd())
+	rootCmd.AddCommand(explainCmd.Run())
+	rootCmd.AddCommand(exportCmd.Run())
+	rootCmd.AddCommand(importCmd.Run())
 	rootCmd.AddCommand(lsp.Run())
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *validateCmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		slog.Error(err.Error())
 		os.Exit(1)
 	}
@@ -13,6 +13,7 @@ require (
 	github.com/spf13/cobra v1.10.2
 	github.com/zclconf/go-cty v1.16.3
 	golang.org/x/term v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
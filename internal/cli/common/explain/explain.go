@@ -0,0 +1,133 @@
+package explain
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/semantic"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/spf13/cobra"
+)
+
+// Run returns a cobra.Command that prints the catalog entry of a diagnostic code.
+func Run() *cobra.Command {
+	var list, markdown bool
+
+	cmd := &cobra.Command{
+		Use:   "explain [flags] <code>",
+		Short: "Explain a diagnostic code",
+		Example: `  irex explain service.model.not_found
+  irex explain --list
+  irex explain --markdown > docs/diagnostics.md`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if list || markdown {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			switch {
+			case list:
+				for _, info := range diagnostics.Codes() {
+					fmt.Fprintf(out, "%-30s %-8s %s\n", info.Code, info.Severity, info.Title)
+				}
+				return nil
+			case markdown:
+				writeMarkdown(out)
+				return nil
+			}
+
+			info, ok := diagnostics.LookupCode(args[0])
+			if !ok {
+				msg := fmt.Sprintf("unknown diagnostic code %q", args[0])
+				codes := make([]string, 0)
+				for _, c := range diagnostics.Codes() {
+					codes = append(codes, c.Code)
+				}
+				if best := semantic.ClosestName(args[0], codes); best != "" {
+					msg += fmt.Sprintf(", did you mean %q?", best)
+				}
+				return fmt.Errorf("%s (see irex explain --list)", msg)
+			}
+			writeText(out, info)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "list every diagnostic code")
+	cmd.Flags().BoolVar(&markdown, "markdown", false, "print the whole catalog as Markdown")
+
+	return cmd
+}
+
+func writeText(w io.Writer, info diagnostics.CodeInfo) {
+	fmt.Fprintf(w, "%s: %s\n", info.Code, info.Title)
+	fmt.Fprintf(w, "Default severity: %s\n\n", info.Severity)
+	fmt.Fprintln(w, info.Explanation)
+	if info.Bad != "" {
+		fmt.Fprintf(w, "\nExample that triggers it:\n\n%s\n", indent(info.Bad))
+	}
+	if info.Good != "" {
+		fmt.Fprintf(w, "\nFixed:\n\n%s\n", indent(info.Good))
+	}
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
+
+// writeMarkdown renders the catalog as published at diagnostics.DocsURL.
+// Each entry has an anchor named after its code, which the language server
+// links to from diagnostics.
+func writeMarkdown(w io.Writer) {
+	fmt.Fprintln(w, "# Diagnostic codes")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "<!-- Generated by `irex explain --markdown`; do not edit by hand. -->")
+	fmt.Fprint(w, suppressionDocs)
+	for _, info := range diagnostics.Codes() {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "<a id=\"%s\"></a>\n\n", info.Code)
+		fmt.Fprintf(w, "## `%s`\n\n", info.Code)
+		fmt.Fprintf(w, "**%s** (default severity: %s)\n\n", info.Title, info.Severity)
+		fmt.Fprintln(w, info.Explanation)
+		if info.Bad != "" {
+			fmt.Fprintf(w, "\nTriggers the diagnostic:\n\n```hcl\n%s\n```\n", info.Bad)
+		}
+		if info.Good != "" {
+			fmt.Fprintf(w, "\nFixed:\n\n```hcl\n%s\n```\n", info.Good)
+		}
+	}
+}
+
+const suppressionDocs = `
+## Changing severities and suppressing diagnostics
+
+The ` + "`lint`" + ` block of irex.hcl changes the severity of a code for the whole
+project. Severities are ` + "`error`, `warn`, `info`, `hint` and `off`" + `:
+
+` + "```hcl" + `
+lint {
+  severity = {
+    "irex.input.recommended" = "off"
+  }
+}
+` + "```" + `
+
+A ` + "`# irex:ignore <code>[, <code>...]`" + ` comment suppresses diagnostics for the
+block or attribute on the next line, including everything nested in it. Placed
+after code it covers its own line only. The comment must name its codes:
+
+` + "```hcl" + `
+# irex:ignore irex.input.recommended
+policy "auth" {
+  rule = "ctx.auth != null"
+}
+` + "```" + `
+
+Codes whose default severity is error (parse, reference and required-input
+problems) cannot be set to anything but ` + "`error`" + ` or ignored, and they always
+stop the build. A code raised to ` + "`error`" + ` stops the build too, for
+` + "`validate`" + `, ` + "`export`" + `, generation and the Go SDK alike.
+`
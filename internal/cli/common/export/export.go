@@ -0,0 +1,137 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/resolve"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/kwizyHQ/irex/internal/export/client"
+	"github.com/kwizyHQ/irex/internal/export/openapi"
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/spf13/cobra"
+)
+
+// buildFlags are the project flags every export takes.
+type buildFlags struct {
+	output   string
+	varFlags []string
+	varFiles []string
+	profile  string
+}
+
+func (f *buildFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.output, "output", "o", "", "write to this file instead of stdout")
+	cmd.Flags().StringArrayVar(&f.varFlags, "var", nil, "set a variable declared in irex.hcl, as name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&f.varFiles, "var-file", nil, "read variables from an HCL file of name = value lines (repeatable)")
+	cmd.Flags().StringVar(&f.profile, "profile", "", "merge the named profile blocks onto the specs (default $IREX_PROFILE)")
+}
+
+// build builds the project at configPath. When any diagnostic is an error,
+// after lint overrides, it prints the errors to errOut and fails, even if
+// an IR was assembled.
+func (f *buildFlags) build(configPath string, errOut io.Writer) (*ir.IRBundle, error) {
+	values, err := resolve.ParseVarFlags(f.varFlags)
+	if err != nil {
+		return nil, err
+	}
+	if abs, err := filepath.Abs(configPath); err == nil {
+		configPath = abs
+	}
+	bundle, diags := pipeline.Build(pipeline.BuildOptions{
+		ConfigPath: configPath,
+		Vars:       resolve.VarOptions{Files: f.varFiles, Values: values},
+		Profile:    f.profile,
+	})
+	if bundle != nil && diags.MaxSeverity() != diagnostics.SeverityError {
+		return bundle, nil
+	}
+	for _, d := range pipeline.LocateDiagnostics(configPath, nil, diags) {
+		if d.Severity == diagnostics.SeverityError {
+			fmt.Fprintf(errOut, "%s:%d: %s\n", d.Filename, d.Range.Start.Line, d.Message)
+		}
+	}
+	return nil, fmt.Errorf("the project has errors, see irex validate %s", configPath)
+}
+
+// write encodes v as indented JSON to the output file or w.
+func (f *buildFlags) write(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return f.writeRaw(w, append(data, '\n'))
+}
+
+// writeRaw writes data to the output file or w.
+func (f *buildFlags) writeRaw(w io.Writer, data []byte) error {
+	if f.output == "" {
+		_, err := w.Write(data)
+		return err
+	}
+	return os.WriteFile(f.output, data, 0644)
+}
+
+// Run returns the `irex export` command.
+func Run() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the project to other formats",
+	}
+	cmd.AddCommand(openAPICmd(), clientCmd())
+	return cmd
+}
+
+func openAPICmd() *cobra.Command {
+	var flags buildFlags
+	cmd := &cobra.Command{
+		Use:   "openapi [flags] <config.hcl>",
+		Short: "Export an OpenAPI 3.1 document of the project's routes",
+		Long: `Export an OpenAPI 3.1 document, as JSON, built from the project's routes,
+operations and models.`,
+		Example: `  irex export openapi irex.hcl -o openapi.json`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The arguments parsed; a failing project needs no usage text.
+			cmd.SilenceUsage = true
+			bundle, err := flags.build(args[0], cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			return flags.write(cmd.OutOrStdout(), openapi.Build(bundle))
+		},
+	}
+	flags.register(cmd)
+	return cmd
+}
+
+func clientCmd() *cobra.Command {
+	var flags buildFlags
+	cmd := &cobra.Command{
+		Use:   "client [flags] <config.hcl>",
+		Short: "Export a typed TypeScript client of the project's routes",
+		Long: `Export a TypeScript client, as a single file without dependencies, with one
+method per operation typed after the project's routes and models.`,
+		Example: `  irex export client irex.hcl -o web/src/api.ts`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The arguments parsed; a failing project needs no usage text.
+			cmd.SilenceUsage = true
+			bundle, err := flags.build(args[0], cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			source, err := client.Generate(bundle)
+			if err != nil {
+				return err
+			}
+			return flags.writeRaw(cmd.OutOrStdout(), source)
+		},
+	}
+	flags.register(cmd)
+	return cmd
+}
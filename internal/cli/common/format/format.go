@@ -10,32 +10,33 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
-	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/kwizyHQ/irex/internal/core/format"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
 // NewFormatCmd returns a cobra.Command that formats HCL files using the internal formatter.
 func Run() *cobra.Command {
-	var check, overwrite, requireNoChange bool
+	var check, overwrite, requireNoChange, canonical bool
 
 	cmd := &cobra.Command{
 		Use:   "format [flags] [paths...]",
 		Short: "Format HCL files",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runHCLFmt(args, check, overwrite, requireNoChange)
+			return runHCLFmt(args, check, overwrite, requireNoChange, format.Options{Canonical: canonical})
 		},
 	}
 
 	cmd.Flags().BoolVar(&check, "check", false, "perform a syntax check on the given files and produce diagnostics")
 	cmd.Flags().BoolVarP(&overwrite, "w", "w", false, "overwrite source files instead of writing to stdout")
 	cmd.Flags().BoolVar(&requireNoChange, "require-no-change", false, "return a non-zero status if any files are changed during formatting")
+	cmd.Flags().BoolVar(&canonical, "canonical", false, "also sort attributes into the order defined by the spec docs")
 	cmd.Flags().Lookup("w").NoOptDefVal = "true"
 
 	return cmd
 }
 
-func runHCLFmt(paths []string, check, overwrite, requireNoChange bool) error {
+func runHCLFmt(paths []string, check, overwrite, requireNoChange bool, opts format.Options) error {
 	parser := hclparse.NewParser()
 	color := term.IsTerminal(int(os.Stderr.Fd()))
 	w, _, err := term.GetSize(int(os.Stdout.Fd()))
@@ -51,7 +52,7 @@ func runHCLFmt(paths []string, check, overwrite, requireNoChange bool) error {
 		if overwrite {
 			return errors.New("error: cannot use -w without source filenames")
 		}
-		return processHclFile("<stdin>", os.Stdin, parser, diagWr, check, overwrite, &checkErrs, &changed)
+		return processHclFile("<stdin>", os.Stdin, parser, diagWr, check, overwrite, opts, &checkErrs, &changed)
 	}
 
 	for _, path := range paths {
@@ -62,7 +63,7 @@ func runHCLFmt(paths []string, check, overwrite, requireNoChange bool) error {
 		if info.IsDir() {
 			return fmt.Errorf("can't format directory %s", path)
 		}
-		if err := processHclFile(path, nil, parser, diagWr, check, overwrite, &checkErrs, &changed); err != nil {
+		if err := processHclFile(path, nil, parser, diagWr, check, overwrite, opts, &checkErrs, &changed); err != nil {
 			return err
 		}
 	}
@@ -76,7 +77,7 @@ func runHCLFmt(paths []string, check, overwrite, requireNoChange bool) error {
 	return nil
 }
 
-func processHclFile(fn string, in *os.File, parser *hclparse.Parser, diagWr hcl.DiagnosticWriter, check, overwrite bool, checkErrs *bool, changed *[]string) error {
+func processHclFile(fn string, in *os.File, parser *hclparse.Parser, diagWr hcl.DiagnosticWriter, check, overwrite bool, opts format.Options, checkErrs *bool, changed *[]string) error {
 	var err error
 	hasLocalChanges := false
 	if in == nil {
@@ -104,7 +105,7 @@ func processHclFile(fn string, in *os.File, parser *hclparse.Parser, diagWr hcl.
 		}
 	}
 
-	outSrc := hclwrite.Format(inSrc)
+	outSrc := format.Source(fn, inSrc, opts)
 
 	if !bytes.Equal(inSrc, outSrc) {
 		*changed = append(*changed, fn)
@@ -0,0 +1,124 @@
+package importcmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/import/openapi"
+	"github.com/spf13/cobra"
+)
+
+// Run returns the `irex import` command.
+func Run() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import specs from other formats",
+	}
+	cmd.AddCommand(openAPICmd())
+	return cmd
+}
+
+func openAPICmd() *cobra.Command {
+	var (
+		configPath string
+		name       string
+		force      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "openapi [flags] <openapi.yaml|openapi.json>",
+		Short: "Import an OpenAPI 3 document as schema and service specs",
+		Long: `Import an OpenAPI 3 document, YAML or JSON, into the project's specifications
+folder: its object schemas become models, paths shaped as CRUD operations on
+a model become model services and the other operations custom ones. What
+could not be mapped is listed after the files are written.`,
+		Example: `  irex import openapi petstore.yaml
+  irex import openapi api.json --config svc/irex.hcl --name api`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			res, err := openapi.Import(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", args[0], err)
+			}
+
+			specDir := pipeline.SpecDir(configPath, nil)
+			if specDir == "" {
+				return fmt.Errorf("cannot read the project config %s", configPath)
+			}
+			if name == "" {
+				name = strings.TrimSuffix(filepath.Base(args[0]), filepath.Ext(args[0]))
+			}
+			schemaFile := filepath.Join(specDir, "schema", name+".hcl")
+			serviceFile := filepath.Join(specDir, "service", name+".hcl")
+			service := res.Service
+			// irex reads a single service file: merge into it when there
+			// is one already.
+			_, serviceFiles := pipeline.SpecFiles(configPath, nil)
+			merged := len(serviceFiles) > 0
+			if merged {
+				serviceFile = serviceFiles[0]
+				existing, err := os.ReadFile(serviceFile)
+				if err != nil {
+					return err
+				}
+				var notes []string
+				service, notes, err = openapi.MergeService(existing, res.Service)
+				if err != nil {
+					return fmt.Errorf("%s: %w", serviceFile, err)
+				}
+				res.Unmapped = append(res.Unmapped, notes...)
+			}
+			if !force {
+				if _, err := os.Stat(schemaFile); err == nil {
+					return fmt.Errorf("%s exists; pass --force to overwrite it or --name to pick another", schemaFile)
+				}
+				if _, err := os.Stat(serviceFile); err == nil && !merged {
+					return fmt.Errorf("%s exists; pass --force to overwrite it or --name to pick another", serviceFile)
+				}
+			}
+			for _, f := range []struct {
+				path    string
+				content []byte
+			}{{schemaFile, res.Schema}, {serviceFile, service}} {
+				if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+					return err
+				}
+				if err := os.WriteFile(f.path, f.content, 0644); err != nil {
+					return err
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Wrote %s (%d models)\n", schemaFile, res.Models)
+			verb := "Wrote"
+			if merged {
+				verb = "Merged into"
+			}
+			fmt.Fprintf(out, "%s %s (%d services, %d operations)\n", verb, serviceFile, res.Services, res.Operations)
+			printUnmapped(out, res.Unmapped)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&configPath, "config", "irex.hcl", "the project's irex.hcl")
+	cmd.Flags().StringVar(&name, "name", "", "base name of the written spec files (default the document's file name)")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite existing spec files")
+	return cmd
+}
+
+func printUnmapped(out io.Writer, unmapped []string) {
+	if len(unmapped) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\nNot mapped (%d):\n", len(unmapped))
+	for _, line := range unmapped {
+		fmt.Fprintf(out, "  - %s\n", line)
+	}
+}
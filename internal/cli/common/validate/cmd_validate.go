@@ -3,18 +3,54 @@ package validate
 import (
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/resolve"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
 	"github.com/spf13/cobra"
 )
 
+// Exit codes of `irex validate`, by the most severe diagnostic reported.
+const (
+	exitOK       = 0
+	exitErrors   = 1
+	exitWarnings = 2
+)
+
+// ExitError carries the exit code of a validation that reported errors or
+// warnings. The diagnostics are already printed, so main exits with Code
+// without printing the error.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("validation exited with code %d", e.Code)
+}
+
 // NewValidateCmd returns a cobra.Command that validates the config file and prints diagnostics.
 func NewValidateCmd() *cobra.Command {
+	var format string
+	var varFlags, varFiles []string
+	var profile string
 	cmd := &cobra.Command{
 		Use:   "validate [flags] <config.hcl>",
 		Short: "Validate IREX config file",
-		Args:  cobra.ExactArgs(1),
+		Long: `Validate an IREX project and print its diagnostics.
+
+The exit code reflects the most severe diagnostic: 1 for errors, 2 for
+warnings and 0 when there are only informational hints or none at all.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(formats, format) {
+				return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(formats, ", "))
+			}
+			values, err := resolve.ParseVarFlags(varFlags)
+			if err != nil {
+				return err
+			}
 			configPath := args[0]
 			if !filepath.IsAbs(configPath) {
 				absPath, err := filepath.Abs(configPath)
@@ -22,15 +58,47 @@ func NewValidateCmd() *cobra.Command {
 					configPath = absPath
 				}
 			}
-			println("Validating config file:", configPath)
+			if format == formatText {
+				println("Validating config file:", configPath)
+			}
 			ctx, diags := pipeline.Build(pipeline.BuildOptions{
 				ConfigPath: configPath,
+				Vars:       resolve.VarOptions{Files: varFiles, Values: values},
+				Profile:    profile,
 			})
 			_ = ctx // ctx can be used for further processing if needed
-			print(diags.Error())
-			fmt.Println("Validation successful.")
+			diags = pipeline.LocateDiagnostics(configPath, nil, diags)
+
+			if err := writeDiagnostics(cmd.OutOrStdout(), format, diags); err != nil {
+				return err
+			}
+
+			code := exitOK
+			switch diags.MaxSeverity() {
+			case diagnostics.SeverityError:
+				code = exitErrors
+			case diagnostics.SeverityWarning:
+				code = exitWarnings
+			}
+			if format == formatText {
+				switch code {
+				case exitOK:
+					fmt.Fprintln(cmd.OutOrStdout(), "Validation successful.")
+				case exitWarnings:
+					fmt.Fprintln(cmd.OutOrStdout(), "Validation finished with warnings.")
+				}
+			}
+			if code != exitOK {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+				return &ExitError{Code: code}
+			}
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&format, "format", formatText, "output format: "+strings.Join(formats, "|"))
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "set a variable declared in irex.hcl, as name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "read variables from an HCL file of name = value lines (repeatable)")
+	cmd.Flags().StringVar(&profile, "profile", "", "merge the named profile blocks onto the specs (default $IREX_PROFILE)")
 	return cmd
 }
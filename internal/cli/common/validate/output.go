@@ -0,0 +1,389 @@
+package validate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"golang.org/x/term"
+)
+
+// output formats accepted by --format
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatSARIF  = "sarif"
+	formatGitHub = "github"
+)
+
+var formats = []string{formatText, formatJSON, formatSARIF, formatGitHub}
+
+func writeDiagnostics(w io.Writer, format string, diags diagnostics.Diagnostics) error {
+	switch format {
+	case formatText:
+		return writeText(w, diags)
+	case formatJSON:
+		return writeJSON(w, diags)
+	case formatSARIF:
+		return writeSARIF(w, diags)
+	case formatGitHub:
+		return writeGitHub(w, diags)
+	default:
+		return fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(formats, ", "))
+	}
+}
+
+// textLabels name the severities HCL's text writer has no label for, with
+// the colour their header is printed in.
+var textLabels = map[diagnostics.Severity]struct{ label, color string }{
+	diagnostics.SeverityInformation: {"Info", "\x1b[36m"},
+	diagnostics.SeverityHint:        {"Hint", "\x1b[36m"},
+}
+
+// writeText prints diagnostics with source snippets, like `irex format` does
+// for syntax errors.
+func writeText(w io.Writer, diags diagnostics.Diagnostics) error {
+	parser := hclparse.NewParser()
+	hclDiags := make(hcl.Diagnostics, 0, len(diags))
+	for _, d := range diags {
+		hd := &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  d.Message,
+			Detail:   d.Code,
+		}
+		if d.Severity == diagnostics.SeverityError {
+			hd.Severity = hcl.DiagError
+		}
+		for _, r := range d.Related {
+			hd.Detail += "\n" + r.Message
+			if r.Filename != "" {
+				hd.Detail += ": " + relPath(r.Filename)
+				if r.Range.Start.Line > 0 {
+					hd.Detail += fmt.Sprintf(":%d:%d", r.Range.Start.Line, r.Range.Start.Column)
+				}
+			}
+		}
+		if d.Filename != "" && d.Range.Start.Line > 0 {
+			// load the file so the writer can show a snippet
+			if _, ok := parser.Files()[d.Filename]; !ok {
+				if src, err := os.ReadFile(d.Filename); err == nil {
+					parser.ParseHCL(src, d.Filename)
+				}
+			}
+			start := hcl.Pos(d.Range.Start)
+			end := hcl.Pos(d.Range.End)
+			// block level diagnostics span the whole body, only show its first line
+			if end.Line != start.Line {
+				end = start
+			}
+			hd.Subject = &hcl.Range{Filename: d.Filename, Start: start, End: end}
+		}
+		hclDiags = append(hclDiags, hd)
+	}
+
+	width, color := 0, false
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		width, _, _ = term.GetSize(int(f.Fd()))
+		color = true
+	}
+	direct := hcl.NewDiagnosticTextWriter(w, parser.Files(), uint(width), color)
+	for i, hd := range hclDiags {
+		label, ok := textLabels[diags[i].Severity]
+		if !ok {
+			if err := direct.WriteDiagnostic(hd); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeNote(w, parser.Files(), label.label, label.color, hd, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNote prints a diagnostic HCL's text writer has no label for in the
+// same layout: a header, the source lines of its subject and its detail.
+func writeNote(w io.Writer, files map[string]*hcl.File, label, labelColor string, hd *hcl.Diagnostic, color bool) error {
+	var b strings.Builder
+	highlight, reset := "", ""
+	if color {
+		label = labelColor + label + "\x1b[0m"
+		highlight, reset = "\x1b[1;4m", "\x1b[0m"
+	}
+	fmt.Fprintf(&b, "%s: %s\n\n", label, hd.Summary)
+
+	if subject := hd.Subject; subject != nil {
+		file := files[subject.Filename]
+		if file == nil || file.Bytes == nil {
+			fmt.Fprintf(&b, "  on %s line %d:\n  (source code not available)\n\n", subject.Filename, subject.Start.Line)
+		} else {
+			context := ""
+			if nav, ok := file.Nav.(interface{ ContextString(offset int) string }); ok {
+				if c := nav.ContextString(subject.Start.Byte); c != "" {
+					context = ", in " + c
+				}
+			}
+			fmt.Fprintf(&b, "  on %s line %d%s:\n", subject.Filename, subject.Start.Line, context)
+			marked := *subject
+			if marked.Empty() {
+				marked.End.Byte++
+				marked.End.Column++
+			}
+			sc := hcl.NewRangeScanner(file.Bytes, subject.Filename, bufio.ScanLines)
+			for sc.Scan() {
+				line := sc.Range()
+				if !line.Overlaps(marked) {
+					continue
+				}
+				before, within, after := line.PartitionAround(marked)
+				fmt.Fprintf(&b, "%4d: %s%s%s%s%s\n", line.Start.Line,
+					before.SliceBytes(file.Bytes), highlight, within.SliceBytes(file.Bytes), reset, after.SliceBytes(file.Bytes))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if hd.Detail != "" {
+		fmt.Fprintf(&b, "%s\n\n", hd.Detail)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeJSON(w io.Writer, diags diagnostics.Diagnostics) error {
+	if diags == nil {
+		diags = diagnostics.Diagnostics{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diags)
+}
+
+// relPath makes path relative to the working directory with forward
+// slashes, which is what code scanning and GitHub annotations expect.
+func relPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// ---------------- SARIF 2.1.0 ----------------
+
+// sarifURI is the artifact URI of path: relative to the working directory
+// when it lies below it, otherwise an absolute file:// URI.
+func sarifURI(path string) string {
+	rel := relPath(path)
+	if !filepath.IsAbs(filepath.FromSlash(rel)) {
+		return (&url.URL{Path: rel}).EscapedPath()
+	}
+	abs := filepath.ToSlash(rel)
+	if !strings.HasPrefix(abs, "/") {
+		// Windows drive paths, e.g. C:/spec/models.hcl
+		abs = "/" + abs
+	}
+	return (&url.URL{Scheme: "file", Path: abs}).String()
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+	// RelatedLocations carries Diagnostic.Related
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func sarifLevel(s diagnostics.Severity) string {
+	switch s {
+	case diagnostics.SeverityError:
+		return "error"
+	case diagnostics.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func writeSARIF(w io.Writer, diags diagnostics.Diagnostics) error {
+	ruleSet := map[string]struct{}{}
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		res := sarifResult{
+			RuleID:  d.Code,
+			Level:   sarifLevel(d.Severity),
+			Message: sarifMessage{Text: d.Message},
+		}
+		if d.Code != "" {
+			ruleSet[d.Code] = struct{}{}
+		}
+		if d.Filename != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(d.Filename)},
+			}}
+			if d.Range.Start.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   d.Range.Start.Line,
+					StartColumn: d.Range.Start.Column,
+					EndLine:     d.Range.End.Line,
+					EndColumn:   d.Range.End.Column,
+				}
+			}
+			res.Locations = []sarifLocation{loc}
+		}
+		for _, r := range d.Related {
+			if r.Filename == "" {
+				continue
+			}
+			loc := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(r.Filename)},
+				},
+				Message: &sarifMessage{Text: r.Message},
+			}
+			if r.Range.Start.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   r.Range.Start.Line,
+					StartColumn: r.Range.Start.Column,
+					EndLine:     r.Range.End.Line,
+					EndColumn:   r.Range.End.Column,
+				}
+			}
+			res.RelatedLocations = append(res.RelatedLocations, loc)
+		}
+		results = append(results, res)
+	}
+
+	rules := make([]sarifRule, 0, len(ruleSet))
+	for id := range ruleSet {
+		rules = append(rules, sarifRule{ID: id})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "irex",
+				InformationURI: "https://github.com/kwizyHQ/irex",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+// ---------------- GitHub workflow commands ----------------
+
+// writeGitHub prints one ::error/::warning/::notice workflow command per
+// diagnostic so GitHub Actions annotates the files in the PR.
+func writeGitHub(w io.Writer, diags diagnostics.Diagnostics) error {
+	for _, d := range diags {
+		command := "notice"
+		switch d.Severity {
+		case diagnostics.SeverityError:
+			command = "error"
+		case diagnostics.SeverityWarning:
+			command = "warning"
+		}
+		var props []string
+		if d.Filename != "" {
+			props = append(props, "file="+escapeProperty(relPath(d.Filename)))
+			if d.Range.Start.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", d.Range.Start.Line))
+				if d.Range.Start.Column > 0 {
+					props = append(props, fmt.Sprintf("col=%d", d.Range.Start.Column))
+				}
+				if d.Range.End.Line > 0 {
+					props = append(props, fmt.Sprintf("endLine=%d", d.Range.End.Line))
+				}
+				if d.Range.End.Line == d.Range.Start.Line && d.Range.End.Column > 0 {
+					props = append(props, fmt.Sprintf("endColumn=%d", d.Range.End.Column))
+				}
+			}
+		}
+		if d.Code != "" {
+			props = append(props, "title="+escapeProperty(d.Code))
+		}
+		line := "::" + command
+		if len(props) > 0 {
+			line += " " + strings.Join(props, ",")
+		}
+		if _, err := fmt.Fprintf(w, "%s::%s\n", line, escapeData(d.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
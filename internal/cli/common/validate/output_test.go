@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+func TestWriteTextInfo(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "irex.hcl")
+	src := "project {\n  name = \"demo\"\n}\n"
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := writeText(&out, diagnostics.Diagnostics{{
+		Severity: diagnostics.SeverityInformation,
+		Message:  "Warning: the name is short.",
+		Code:     diagnostics.CodeInputRecommended,
+		Filename: file,
+		Range: diagnostics.Range{
+			Start: diagnostics.Position{Line: 2, Column: 3, Byte: 12},
+			End:   diagnostics.Position{Line: 2, Column: 7, Byte: 16},
+		},
+	}, {
+		Severity: diagnostics.SeverityHint,
+		Message:  "No file.",
+		Code:     diagnostics.CodeInputRecommended,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Info: Warning: the name is short.\n\n" +
+		"  on " + file + " line 2, in project:\n" +
+		"   2:   name = \"demo\"\n\n" +
+		"irex.input.recommended\n\n" +
+		"Hint: No file.\n\n" +
+		"irex.input.recommended\n\n"
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
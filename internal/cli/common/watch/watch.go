@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/kwizyHQ/irex/internal/core/resolve"
 	nodets "github.com/kwizyHQ/irex/internal/engines/node-ts"
 	"github.com/kwizyHQ/irex/internal/ir"
 	"github.com/kwizyHQ/irex/internal/plan"
@@ -21,10 +22,18 @@ var (
 )
 
 func Run() *cobra.Command {
+	var varFlags, varFiles []string
+	var profile string
 	cmd := &cobra.Command{
 		Use:   "watch",
 		Short: "Watch mode (placeholder)",
 		Run: func(cmd *cobra.Command, args []string) {
+			values, err := resolve.ParseVarFlags(varFlags)
+			if err != nil {
+				slog.Error(err.Error())
+				os.Exit(1)
+			}
+
 			// 🔴 DO NOT use signal.NotifyContext (Cobra exits early on Windows)
 			ctx, cancel := context.WithCancel(comCtx)
 
@@ -46,7 +55,7 @@ func Run() *cobra.Command {
 				ID:   "watch",
 				Name: "Watch server",
 				Steps: []plan.Step{
-					&steps.LoadIR{IRPath: "irex.hcl"},
+					&steps.LoadIR{IRPath: "irex.hcl", Vars: resolve.VarOptions{Files: varFiles, Values: values}, Profile: profile},
 					&steps.PlanSelectorStep{
 						PlansMap: map[string]func(ctx *plan.PlanContext) *plan.Plan{
 							"node-ts": nodets.NodeTSWatchPlan,
@@ -109,6 +118,9 @@ func Run() *cobra.Command {
 			select {} // block forever, we exit explicitly
 		},
 	}
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "set a variable declared in irex.hcl, as name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "read variables from an HCL file of name = value lines (repeatable)")
+	cmd.Flags().StringVar(&profile, "profile", "", "merge the named profile blocks onto the specs (default $IREX_PROFILE)")
 	cmd.SetContext(comCtx)
 	return cmd
 }
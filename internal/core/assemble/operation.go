@@ -35,11 +35,12 @@ func prepareInferredOperationsIR(ctx *shared.BuildContext, svc *symbols.Service,
 	if ctx.IR.Operations == nil {
 		ctx.IR.Operations = make(ir.IROperations)
 	}
-	// determine requested CRUD operations (service-level overrides defaults)
+	// determine requested CRUD operations (service-level overrides defaults);
+	// a list resolved to empty is set and disables them all
 	var crudOps []string
-	if len(svc.CrudOperations) > 0 {
+	if svc.CrudOperations != nil {
 		crudOps = svc.CrudOperations
-	} else if svc.Defaults != nil && len(svc.Defaults.CrudOperations) > 0 {
+	} else if svc.Defaults != nil && svc.Defaults.CrudOperations != nil {
 		crudOps = svc.Defaults.CrudOperations
 	}
 
@@ -150,6 +151,10 @@ func prepareInferredOperationsIR(ctx *shared.BuildContext, svc *symbols.Service,
 		} else if svc.Defaults != nil && svc.Defaults.Pagination != nil {
 			paginated = *svc.Defaults.Pagination
 		}
+		var sorting, filtering []string
+		if svc.Defaults != nil {
+			sorting, filtering = svc.Defaults.Sorting, svc.Defaults.Filtering
+		}
 		op := ir.IROperation{
 			Name:    name,
 			Service: svc.Name,
@@ -160,6 +165,8 @@ func prepareInferredOperationsIR(ctx *shared.BuildContext, svc *symbols.Service,
 				Action:      ir.DataList,
 				Target:      "many",
 				Paginated:   paginated,
+				Sorting:     sorting,
+				Filtering:   filtering,
 				ReturnsList: true,
 			},
 		}
@@ -3,28 +3,29 @@ package assemble
 import (
 	"time"
 
+	"github.com/kwizyHQ/irex/internal/core/functions"
 	"github.com/kwizyHQ/irex/internal/core/shared"
 	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/zclconf/go-cty/cty"
 )
 
-// resolveEnvRef extracts the name from functions.EnvRef. For now we just
-// return the Name field as the resolved value. More advanced resolution
-// (env lookup, secret handling) can be added later.
-// func resolveEnvRef(ref *symbols.RuntimeSchemaOptions) (string, string) {
-// 	if ref == nil {
-// 		return "", ""
-// 	}
-// 	var uri, db string
-// 	if ref.Operations != nil {
-// 		if ref.Options.URI.Name != "" {
-// 			uri = ref.Options.URI.Name
-// 		}
-// 		if ref.Options.DB.Name != "" {
-// 			db = ref.Options.DB.Name
-// 		}
-// 	}
-// 	return uri, db
-// }
+// irValue converts a literal or env() attribute to its IR form. Invalid
+// values were already reported by validation and become empty.
+func irValue(v cty.Value) ir.IRValue {
+	val, err := functions.DecodeValue(v)
+	if err != nil {
+		return ir.IRValue{}
+	}
+	if val.Ref == nil {
+		return ir.IRValue{Literal: val.Literal}
+	}
+	out := ir.IRValue{Env: val.Ref.Name, Secret: val.Ref.Kind == functions.EnvKindSecret}
+	if val.Ref.Default != nil {
+		out.Default = *val.Ref.Default
+		out.HasDefault = true
+	}
+	return out
+}
 
 func prepareConfigIR(ctx *shared.BuildContext) error {
 	if ctx == nil || ctx.ConfigAST == nil || ctx.ConfigAST.Project == nil {
@@ -48,13 +49,7 @@ func prepareConfigIR(ctx *shared.BuildContext) error {
 		},
 	}
 
-	if p.Paths != nil {
-		cfg.Paths = ir.IRPaths{
-			Specifications: p.Paths.Specifications,
-			Templates:      p.Paths.Templates,
-			Output:         p.Paths.Output,
-		}
-	}
+	cfg.Paths = ctx.Paths
 
 	if p.Generator != nil {
 		cfg.Generator = ir.IRGenerator{
@@ -86,12 +81,9 @@ func prepareConfigIR(ctx *shared.BuildContext) error {
 				Version:   p.Runtime.Schema.Version,
 			}
 			if p.Runtime.Schema.Options != nil {
-				// resolve env refs to plain strings (names)
-				if p.Runtime.Schema.Options.URI.Name != "" {
-					rt.Schema.Database.URI = p.Runtime.Schema.Options.URI.Name
-				}
-				if p.Runtime.Schema.Options.DB.Name != "" {
-					rt.Schema.Database.DB = p.Runtime.Schema.Options.DB.Name
+				rt.Schema.Database = ir.IRDatabaseConfig{
+					URI: irValue(p.Runtime.Schema.Options.URI),
+					DB:  irValue(p.Runtime.Schema.Options.DB),
 				}
 			}
 		}
@@ -119,6 +111,7 @@ func prepareConfigIR(ctx *shared.BuildContext) error {
 			GeneratorVersion: p.Meta.GeneratorVersion,
 		}
 	}
+	cfg.Meta.Profile = ctx.Profile
 
 	ctx.IR.Config = cfg
 	return nil
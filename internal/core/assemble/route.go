@@ -2,6 +2,7 @@ package assemble
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/kwizyHQ/irex/internal/core/shared"
 	"github.com/kwizyHQ/irex/internal/core/symbols"
@@ -26,6 +27,7 @@ func prepareRouteIR(ctx *shared.BuildContext, operationName, method, path, servi
 		ID:        id,
 		Method:    method,
 		Path:      path,
+		Segments:  parseSegments(path),
 		Service:   service,
 		Operation: operationName,
 	}
@@ -55,3 +57,29 @@ func prepareRouteIR(ctx *shared.BuildContext, operationName, method, path, servi
 	ctx.IR.Routes[id] = route
 	return nil
 }
+
+// parseSegments splits a route path into its segments: `:name` params,
+// `:name?` optional params, `:name(regex)` constrained params, a `*`
+// catch-all and static literals.
+func parseSegments(path string) []ir.PathSegment {
+	var out []ir.PathSegment
+	for _, part := range strings.Split(path, "/") {
+		switch {
+		case part == "":
+			continue
+		case part == "*":
+			out = append(out, ir.PathSegment{Kind: ir.SegmentCatchAll, Name: "*"})
+		case strings.HasPrefix(part, ":"):
+			seg := ir.PathSegment{Kind: ir.SegmentParam, Name: part[1:]}
+			if i := strings.IndexByte(seg.Name, '('); i > 0 && strings.HasSuffix(seg.Name, ")") {
+				seg.Kind, seg.Regex, seg.Name = ir.SegmentRegex, seg.Name[i+1:len(seg.Name)-1], seg.Name[:i]
+			} else if name, ok := strings.CutSuffix(seg.Name, "?"); ok {
+				seg.Kind, seg.Name = ir.SegmentOptional, name
+			}
+			out = append(out, seg)
+		default:
+			out = append(out, ir.PathSegment{Kind: ir.SegmentStatic, Literal: part})
+		}
+	}
+	return out
+}
@@ -2,28 +2,77 @@ package ast
 
 import (
 	"encoding/json"
-	"os"
+	"errors"
+	"io/fs"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsimple"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
 	"github.com/kwizyHQ/irex/internal/diagnostics"
 )
 
 func ParseHCL[T any](path string, def *T) diagnostics.Diagnostics {
+	return ParseHCLFS(overlay.OS, path, def)
+}
+
+// Options control how a spec file is decoded.
+type Options struct {
+	// Eval evaluates expressions, e.g. one from functions.EvalContext that
+	// knows the project's variables. Nil means functions.EvalContext(nil).
+	Eval *hcl.EvalContext
+	// Profile names the profile blocks merged onto config and service
+	// specs; profile blocks are dropped when empty.
+	Profile string
+}
+
+// ParseHCLFS is ParseHCL reading through fsys, so open editor buffers win over disk.
+func ParseHCLFS[T any](fsys overlay.FS, path string, def *T) diagnostics.Diagnostics {
+	return ParseHCLFSWith(fsys, path, def, Options{})
+}
+
+// ParseHCLFSWith is ParseHCLFS decoding with opts.
+func ParseHCLFSWith[T any](fsys overlay.FS, path string, def *T, opts Options) diagnostics.Diagnostics {
 	r := diagnostics.NewReporter()
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		r.Error("We couldn't find the file at "+path, diagnostics.Range{}, "config.not_found", "pipeline")
+	src, err := overlay.Or(fsys).ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.Error("We couldn't find the file at "+path, diagnostics.Range{}, diagnostics.CodeConfigNotFound, "pipeline")
 		return r.All()
 	}
-	ctx := &hcl.EvalContext{
-		Functions: functions.ASTFunctions,
+	if err != nil {
+		r.Error("We couldn't read the file at "+path+": "+err.Error(), diagnostics.Range{}, diagnostics.CodeConfigReadError, "pipeline")
+		return r.All()
 	}
-	err := hclsimple.DecodeFile(path, ctx, def)
-	r.FromHCL(err)
+	r.FromHCL(decode(path, src, def, opts))
 	return r.All()
 }
 
+// decode is hclsimple.Decode that applies opts.Profile to native syntax
+// config and service specs.
+func decode(path string, src []byte, def any, opts Options) error {
+	evalCtx := opts.Eval
+	if evalCtx == nil {
+		evalCtx = functions.EvalContext(nil)
+	}
+	if !acceptsProfiles(def) || strings.HasSuffix(path, ".json") {
+		return hclsimple.Decode(path, src, evalCtx, def)
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+	body, profileDiags := applyProfile(file.Body.(*hclsyntax.Body), opts.Profile)
+	diags = append(diags, profileDiags...)
+	diags = append(diags, gohcl.DecodeBody(body, evalCtx, def)...)
+	if len(diags) == 0 {
+		return nil
+	}
+	return diags
+}
+
 func ParseToJson[T any](path string, def *T) (string, error) {
 	err := ParseHCL(path, def)
 	if len(err) > 0 {
@@ -42,11 +91,13 @@ func ToJSON(v any) (string, error) {
 }
 
 func ParseFromHCLContent[T any](path string, content string, def *T) diagnostics.Diagnostics {
+	return ParseFromHCLContentWith(path, content, def, Options{})
+}
+
+// ParseFromHCLContentWith is ParseFromHCLContent decoding with opts.
+func ParseFromHCLContentWith[T any](path string, content string, def *T, opts Options) diagnostics.Diagnostics {
 	r := diagnostics.NewReporter()
-	ctx := &hcl.EvalContext{
-		Functions: functions.ASTFunctions,
-	}
-	err := hclsimple.Decode(path, []byte(content), ctx, def)
+	err := decode(path, []byte(content), def, opts)
 	r.FromHCL(err)
 	return r.All()
 }
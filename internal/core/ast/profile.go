@@ -0,0 +1,103 @@
+package ast
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+)
+
+// ProfileBlock is the block type of environment overlays, e.g.
+//
+//	profile "prod" {
+//	  project { runtime { options { entry = "dist/index.js" } } }
+//	}
+const ProfileBlock = "profile"
+
+// acceptsProfiles reports whether def is a spec that may contain profile blocks.
+func acceptsProfiles(def any) bool {
+	switch def.(type) {
+	case *symbols.ConfigDefinition, *symbols.ServiceDefinition:
+		return true
+	}
+	return false
+}
+
+// applyProfile returns body without its top-level profile blocks, with the
+// blocks named profile deep-merged onto it in file order.
+func applyProfile(body *hclsyntax.Body, profile string) (*hclsyntax.Body, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	out := *body
+	out.Blocks = nil
+	var overlays []*hclsyntax.Block
+	for _, b := range body.Blocks {
+		if b.Type != ProfileBlock {
+			out.Blocks = append(out.Blocks, b)
+			continue
+		}
+		if len(b.Labels) != 1 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "A profile block takes exactly one label, the profile name.",
+				Subject:  b.DefRange().Ptr(),
+			})
+			continue
+		}
+		if profile != "" && b.Labels[0] == profile {
+			overlays = append(overlays, b)
+		}
+	}
+	merged := &out
+	for _, o := range overlays {
+		merged = mergeBody(merged, o.Body)
+	}
+	return merged, diags
+}
+
+// mergeBody deep-merges overlay onto base: overlay attributes replace base
+// ones, blocks with the same type and labels are merged recursively and
+// other blocks are appended. Neither body is modified.
+func mergeBody(base, overlay *hclsyntax.Body) *hclsyntax.Body {
+	out := *base
+	out.Attributes = make(hclsyntax.Attributes, len(base.Attributes)+len(overlay.Attributes))
+	for name, attr := range base.Attributes {
+		out.Attributes[name] = attr
+	}
+	for name, attr := range overlay.Attributes {
+		out.Attributes[name] = attr
+	}
+	out.Blocks = slices.Clone(base.Blocks)
+	for _, ob := range overlay.Blocks {
+		i := slices.IndexFunc(out.Blocks, func(b *hclsyntax.Block) bool {
+			return b.Type == ob.Type && slices.Equal(b.Labels, ob.Labels)
+		})
+		if i < 0 {
+			out.Blocks = append(out.Blocks, ob)
+			continue
+		}
+		merged := *out.Blocks[i]
+		merged.Body = mergeBody(out.Blocks[i].Body, ob.Body)
+		out.Blocks[i] = &merged
+	}
+	return &out
+}
+
+// Profiles returns the names of the profile blocks declared in src.
+func Profiles(filename string, src []byte) []string {
+	file, _ := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if file == nil {
+		return nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, b := range body.Blocks {
+		if b.Type == ProfileBlock && len(b.Labels) == 1 && !slices.Contains(names, b.Labels[0]) {
+			names = append(names, b.Labels[0])
+		}
+	}
+	return names
+}
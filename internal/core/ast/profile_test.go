@@ -0,0 +1,96 @@
+package ast
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+)
+
+const profileConfig = `project {
+  name = "demo"
+  runtime {
+    service {
+      options {
+        port = 3000
+        host = "localhost"
+      }
+    }
+  }
+}
+
+profile "prod" {
+  project {
+    runtime {
+      service {
+        options {
+          port   = 8080
+          logger = true
+        }
+      }
+    }
+  }
+}
+
+profile "staging" {
+  project {
+    name = "demo-staging"
+  }
+}
+
+profile "prod" {
+  project {
+    runtime {
+      service {
+        options {
+          port = 80
+        }
+      }
+    }
+  }
+}
+`
+
+func TestProfileOverlays(t *testing.T) {
+	tests := []struct {
+		profile string
+		name    string
+		port    int
+		host    string
+		logger  bool
+	}{
+		{profile: "", name: "demo", port: 3000, host: "localhost"},
+		// later blocks of a profile win, untouched attributes are kept
+		{profile: "prod", name: "demo", port: 80, host: "localhost", logger: true},
+		{profile: "staging", name: "demo-staging", port: 3000, host: "localhost"},
+		// Build reports an undeclared profile; decoding just ignores it
+		{profile: "qa", name: "demo", port: 3000, host: "localhost"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			var def symbols.ConfigDefinition
+			if diags := ParseFromHCLContentWith("irex.hcl", profileConfig, &def, Options{Profile: tt.profile}); len(diags) > 0 {
+				t.Fatalf("unexpected diagnostics %+v", diags)
+			}
+			opts := def.Project.Runtime.Service.Options
+			if def.Project.Name != tt.name || opts.Port != tt.port || opts.Host != tt.host || opts.Logger != tt.logger {
+				t.Errorf("got name %q port %d host %q logger %v", def.Project.Name, opts.Port, opts.Host, opts.Logger)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	got := Profiles("irex.hcl", []byte(profileConfig))
+	if !slices.Equal(got, []string{"prod", "staging"}) {
+		t.Errorf("Profiles = %q, want [prod staging]", got)
+	}
+}
+
+func TestProfileNeedsOneLabel(t *testing.T) {
+	var def symbols.ConfigDefinition
+	diags := ParseFromHCLContentWith("irex.hcl", "profile {\n}\n", &def, Options{Profile: "prod"})
+	if len(diags) != 1 || diags[0].Message != "A profile block takes exactly one label, the profile name." {
+		t.Errorf("want the label error, got %+v", diags)
+	}
+}
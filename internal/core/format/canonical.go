@@ -0,0 +1,215 @@
+package format
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+)
+
+// rootSchemas maps a file kind (pipeline.GetFileType) to its decode target.
+// The symbols structs declare their fields in the order of the spec docs, so
+// they double as the canonical attribute order.
+var rootSchemas = map[string]reflect.Type{
+	"config":   reflect.TypeOf(symbols.ConfigDefinition{}),
+	"schema":   reflect.TypeOf(symbols.ModelsSpec{}),
+	"service":  reflect.TypeOf(symbols.ServiceDefinition{}),
+	"template": reflect.TypeOf(symbols.TemplateDefinition{}),
+}
+
+// blockSchema is the canonical attribute order of one block type and the
+// schemas of its nested blocks.
+type blockSchema struct {
+	attrs  map[string]int
+	blocks map[string]*blockSchema
+}
+
+func schemaFor(t reflect.Type, seen map[reflect.Type]*blockSchema) *blockSchema {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if s, ok := seen[t]; ok {
+		return s
+	}
+	s := &blockSchema{attrs: map[string]int{}, blocks: map[string]*blockSchema{}}
+	seen[t] = s
+	if t.Kind() != reflect.Struct {
+		return s
+	}
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("hcl")
+		if tag == "" {
+			continue
+		}
+		name, kind, _ := strings.Cut(tag, ",")
+		switch kind {
+		case "label", "remain":
+		case "block":
+			s.blocks[name] = schemaFor(t.Field(i).Type, seen)
+		default:
+			s.attrs[name] = len(s.attrs)
+		}
+	}
+	return s
+}
+
+// replacement swaps src[start:end] for text.
+type replacement struct {
+	start, end int
+	text       []byte
+}
+
+// canonicalize reorders attributes inside every known block. Only runs of
+// attributes that each sit on their own lines are moved; a blank line or a
+// nested block ends a run, so hand-made grouping survives. Comment lines
+// directly above an attribute travel with it.
+func canonicalize(filename string, src []byte) []byte {
+	root, ok := rootSchemas[pipeline.GetFileType(filename)]
+	if !ok {
+		return src
+	}
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	lines := lineOffsets(src)
+	var reps []replacement
+	var walk func(body *hclsyntax.Body, schema *blockSchema)
+	walk = func(body *hclsyntax.Body, schema *blockSchema) {
+		reps = append(reps, reorderBody(src, lines, body, schema)...)
+		for _, b := range body.Blocks {
+			if child, ok := schema.blocks[b.Type]; ok {
+				walk(b.Body, child)
+			}
+		}
+	}
+	walk(file.Body.(*hclsyntax.Body), schemaFor(root, map[reflect.Type]*blockSchema{}))
+
+	sort.Slice(reps, func(i, j int) bool { return reps[i].start > reps[j].start })
+	out := append([]byte(nil), src...)
+	for _, r := range reps {
+		out = append(out[:r.start], append(append([]byte(nil), r.text...), out[r.end:]...)...)
+	}
+	return out
+}
+
+type item struct {
+	name      string // attribute name, "" for blocks
+	startLine int    // 1-based, first line of the item itself
+	endLine   int
+}
+
+func reorderBody(src []byte, lines []int, body *hclsyntax.Body, schema *blockSchema) []replacement {
+	items := make([]item, 0, len(body.Attributes)+len(body.Blocks))
+	for name, a := range body.Attributes {
+		items = append(items, item{name: name, startLine: a.SrcRange.Start.Line, endLine: a.SrcRange.End.Line})
+	}
+	for _, b := range body.Blocks {
+		items = append(items, item{startLine: b.Range().Start.Line, endLine: b.Range().End.Line})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].startLine < items[j].startLine })
+
+	// bail out on bodies where items share lines (e.g. one-line blocks)
+	for i := 1; i < len(items); i++ {
+		if items[i].startLine <= items[i-1].endLine {
+			return nil
+		}
+	}
+	// the root body starts at byte 0; nested bodies start at their `{`
+	isRoot := body.SrcRange.Start.Byte == 0
+	if !isRoot && len(items) > 0 &&
+		(items[0].startLine == body.SrcRange.Start.Line || items[len(items)-1].endLine == body.SrcRange.End.Line) {
+		return nil
+	}
+
+	type chunk struct {
+		item
+		from int // first line including attached comments
+	}
+	var reps []replacement
+	var run []chunk
+	flush := func() {
+		if len(run) > 1 {
+			sorted := append([]chunk(nil), run...)
+			sort.SliceStable(sorted, func(i, j int) bool {
+				return orderOf(schema, sorted[i].name) < orderOf(schema, sorted[j].name)
+			})
+			var text bytes.Buffer
+			for _, c := range sorted {
+				span := lineSpan(src, lines, c.from, c.endLine)
+				text.Write(span)
+				if !bytes.HasSuffix(span, []byte("\n")) {
+					text.WriteByte('\n')
+				}
+			}
+			start := lines[run[0].from-1]
+			original := lineSpan(src, lines, run[0].from, run[len(run)-1].endLine)
+			out := text.Bytes()
+			if !bytes.HasSuffix(original, []byte("\n")) {
+				out = bytes.TrimSuffix(out, []byte("\n"))
+			}
+			if !bytes.Equal(original, out) {
+				reps = append(reps, replacement{start: start, end: start + len(original), text: out})
+			}
+		}
+		run = nil
+	}
+
+	prevEnd := body.SrcRange.Start.Line
+	if isRoot {
+		prevEnd = 0
+	}
+	for _, it := range items {
+		from := prevEnd + 1
+		// a blank line between items ends the run; comments attach downwards
+		for l := it.startLine - 1; l >= from; l-- {
+			if len(bytes.TrimSpace(lineSpan(src, lines, l, l))) == 0 {
+				flush()
+				from = l + 1
+				break
+			}
+		}
+		if it.name == "" {
+			flush()
+		} else {
+			run = append(run, chunk{item: it, from: from})
+		}
+		prevEnd = it.endLine
+	}
+	flush()
+	return reps
+}
+
+func orderOf(schema *blockSchema, name string) int {
+	if i, ok := schema.attrs[name]; ok {
+		return i
+	}
+	return len(schema.attrs)
+}
+
+// lineOffsets returns the byte offset at which each 1-based line starts
+// (index 0 is line 1).
+func lineOffsets(src []byte) []int {
+	offsets := []int{0}
+	for i, c := range src {
+		if c == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// lineSpan returns lines from..to (1-based, inclusive) including the trailing newline.
+func lineSpan(src []byte, lines []int, from, to int) []byte {
+	start := lines[from-1]
+	end := len(src)
+	if to < len(lines) {
+		end = lines[to]
+	}
+	return src[start:end]
+}
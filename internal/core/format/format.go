@@ -0,0 +1,26 @@
+package format
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Options controls how Source formats a file.
+type Options struct {
+	// Canonical additionally sorts attributes into the order the spec docs
+	// define for each block (see canonical.go).
+	Canonical bool
+}
+
+// Source formats HCL source the same way `irex format` does. Canonical
+// ordering is only applied when the file parses cleanly and its kind
+// (config, schema, service, template) is known from filename.
+func Source(filename string, src []byte, opts Options) []byte {
+	if opts.Canonical {
+		if _, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos); !diags.HasErrors() {
+			src = canonicalize(filename, src)
+		}
+	}
+	return hclwrite.Format(src)
+}
@@ -1,17 +1,42 @@
 package functions
 
 import (
+	"github.com/hashicorp/hcl/v2"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 )
 
-// all functions map as "StringName": function
+// all functions map as "StringName": function. var() here knows no
+// variables; use EvalContext to parse with a project's variables.
 var ASTFunctions = map[string]function.Function{
 	"only":    OnlyFunc,
 	"except":  ExceptFunc,
 	"with":    WithFunc,
 	"without": WithoutFunc,
 	"env":     EnvFunc,
+	"secret":  SecretFunc,
+	"var":     VarFunc(nil),
+}
+
+// EvalContext returns the context specs are decoded with, where var() reads vars.
+func EvalContext(vars map[string]cty.Value) *hcl.EvalContext {
+	fns := make(map[string]function.Function, len(ASTFunctions))
+	for name, fn := range ASTFunctions {
+		fns[name] = fn
+	}
+	fns["var"] = VarFunc(vars)
+	return &hcl.EvalContext{Functions: fns}
+}
+
+// WithLocals returns a child of ctx (EvalContext(nil) when nil) where
+// local.<name> reads locals.
+func WithLocals(ctx *hcl.EvalContext, locals map[string]cty.Value) *hcl.EvalContext {
+	if ctx == nil {
+		ctx = EvalContext(nil)
+	}
+	child := ctx.NewChild()
+	child.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+	return child
 }
 
 // --- Implementation of the `only` function (Similar to `with`) ---
@@ -29,7 +54,7 @@ var OnlyFunc = function.New(&function.Spec{
 
 		return cty.ObjectVal(map[string]cty.Value{
 			// The items passed to the function are the ones to be included
-			"include": cty.ListVal(includeList),
+			"include": stringList(includeList),
 			// We exclude nothing
 			"exclude": cty.ListValEmpty(cty.String),
 			// Setting false means only the 'include' list should be used, ignoring defaults
@@ -53,14 +78,14 @@ var ExceptFunc = function.New(&function.Spec{
 			// We include nothing explicitly
 			"include": cty.ListValEmpty(cty.String),
 			// The items passed to the function are the ones to be excluded
-			"exclude": cty.ListVal(excludeList),
+			"exclude": stringList(excludeList),
 			// Setting true means start with defaults, then apply the 'exclude' list
 			"mergeDefaults": cty.True,
 		}), nil
 	},
 })
 
-// --- Implementation of the `with` function (Alias/Duplicate logic of `only`) ---
+// --- Implementation of the `with` function (adds to the inherited list) ---
 
 // WithFunc is a function.Function wrapper for with
 var WithFunc = function.New(&function.Spec{
@@ -72,10 +97,10 @@ var WithFunc = function.New(&function.Spec{
 		copy(includeList, args)
 
 		return cty.ObjectVal(map[string]cty.Value{
-			"include": cty.ListVal(includeList),
+			"include": stringList(includeList),
 			"exclude": cty.ListValEmpty(cty.String),
-			// This typically implies a replacement of the default list
-			"mergeDefaults": cty.False,
+			// Start from the inherited list and add these
+			"mergeDefaults": cty.True,
 		}), nil
 	},
 })
@@ -93,14 +118,23 @@ var WithoutFunc = function.New(&function.Spec{
 
 		return cty.ObjectVal(map[string]cty.Value{
 			"include": cty.ListValEmpty(cty.String),
-			"exclude": cty.ListVal(excludeList),
+			"exclude": stringList(excludeList),
 			// This typically implies starting with the default list and removing these
 			"mergeDefaults": cty.True,
 		}), nil
 	},
 })
 
-// EnvFunc is a function.Function wrapper for env(key)
+// stringList is cty.ListVal that also accepts no elements.
+func stringList(vals []cty.Value) cty.Value {
+	if len(vals) == 0 {
+		return cty.ListValEmpty(cty.String)
+	}
+	return cty.ListVal(vals)
+}
+
+// EnvFunc is a function.Function wrapper for env(key[, default]). A call
+// without a default marks the variable as required.
 var EnvFunc = function.New(&function.Spec{
 	Params: []function.Parameter{
 		{
@@ -108,15 +142,78 @@ var EnvFunc = function.New(&function.Spec{
 			Type: cty.String,
 		},
 	},
+	VarParam: &function.Parameter{
+		Name: "default",
+		Type: cty.String,
+	},
 	Type: function.StaticReturnType(cty.Object(map[string]cty.Type{
-		"name": cty.String,
-		"kind": cty.String,
+		"name":    cty.String,
+		"kind":    cty.String,
+		"default": cty.String,
 	})),
 	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if len(args) > 2 {
+			return cty.NilVal, function.NewArgErrorf(2, "env() takes a name and an optional default")
+		}
 		name := args[0].AsString()
+		def := cty.NullVal(cty.String)
+		if len(args) == 2 {
+			def = args[1]
+		}
+		return cty.ObjectVal(map[string]cty.Value{
+			"name":    cty.StringVal(name),
+			"kind":    cty.StringVal(string(EnvKindEnv)),
+			"default": def,
+		}), nil
+	},
+})
+
+// SecretFunc is a function.Function wrapper for secret(name). Unlike env()
+// it takes no default: a secret is only ever looked up at runtime, so its
+// value never appears in the IR or in generated code.
+var SecretFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "name",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.Object(map[string]cty.Type{
+		"name":    cty.String,
+		"kind":    cty.String,
+		"default": cty.String,
+	})),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
 		return cty.ObjectVal(map[string]cty.Value{
-			"name": cty.StringVal(name),
-			"kind": cty.StringVal(string(EnvKindEnv)),
+			"name":    args[0],
+			"kind":    cty.StringVal(string(EnvKindSecret)),
+			"default": cty.NullVal(cty.String),
 		}), nil
 	},
 })
+
+// VarFunc returns var(name), which evaluates to the value of a variable
+// declared in the `variables` block of irex.hcl.
+func VarFunc(vars map[string]cty.Value) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "name",
+				Type: cty.String,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			if !args[0].IsKnown() {
+				return cty.DynamicPseudoType, nil
+			}
+			v, ok := vars[args[0].AsString()]
+			if !ok {
+				return cty.NilType, function.NewArgErrorf(0, "variable %q is not declared in the variables block", args[0].AsString())
+			}
+			return v.Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return vars[args[0].AsString()], nil
+		},
+	})
+}
@@ -1,5 +1,13 @@
 package functions
 
+import (
+	"fmt"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
 // EnvKind represents the kind of environment reference (env, var, secret)
 type EnvKind string
 
@@ -13,4 +21,94 @@ const (
 type EnvRef struct {
 	Name string  `hcl:"name,attr" cty:"name"`
 	Kind EnvKind `hcl:"kind,attr" cty:"kind"`
+	// Default is used when the reference is unset; nil makes it required.
+	Default *string `hcl:"default,attr" cty:"default"`
+}
+
+// Value is an attribute that accepts either a literal string, fixed at build
+// time, or a reference such as env("NAME") read at runtime.
+type Value struct {
+	Literal string
+	Ref     *EnvRef
+}
+
+// IsSet reports whether the attribute was given at all.
+func (v Value) IsSet() bool {
+	return v.Ref != nil || v.Literal != ""
+}
+
+// DecodeValue interprets the value of an attribute declared as cty.Value. A
+// null value, or an unknown one left by an expression that failed to
+// evaluate (already reported when parsing), decodes to the zero Value.
+func DecodeValue(v cty.Value) (Value, error) {
+	if v == cty.NilVal || v.IsNull() || !v.IsWhollyKnown() {
+		return Value{}, nil
+	}
+	if v.Type().IsObjectType() && v.Type().HasAttribute("kind") {
+		var ref EnvRef
+		if err := gocty.FromCtyValue(v, &ref); err != nil {
+			return Value{}, err
+		}
+		return Value{Ref: &ref}, nil
+	}
+	var s string
+	if err := gocty.FromCtyValue(v, &s); err != nil {
+		return Value{}, fmt.Errorf("expected a string or env(...)")
+	}
+	return Value{Literal: s}, nil
+}
+
+// Set is a list attribute that also accepts only(), except(), with() and
+// without(). A plain list or only() replaces the inherited list, with()
+// adds to it and except()/without() remove from it.
+type Set struct {
+	Include       []string `cty:"include"`
+	Exclude       []string `cty:"exclude"`
+	MergeDefaults bool     `cty:"mergeDefaults"` // start from the inherited list
+}
+
+// DecodeSet interprets the value of a set attribute declared as cty.Value.
+// ok is false when the attribute was not given (or failed to evaluate).
+func DecodeSet(v cty.Value) (set Set, ok bool, err error) {
+	if v == cty.NilVal || v.IsNull() || !v.IsWhollyKnown() {
+		return Set{}, false, nil
+	}
+	if v.Type().IsObjectType() {
+		if err := gocty.FromCtyValue(v, &set); err != nil {
+			return Set{}, false, fmt.Errorf("expected a list or only(), except(), with() or without()")
+		}
+		return set, true, nil
+	}
+	list, err := convert.Convert(v, cty.List(cty.String))
+	if err != nil {
+		return Set{}, false, fmt.Errorf("expected a list of strings or only(), except(), with() or without()")
+	}
+	if err := gocty.FromCtyValue(list, &set.Include); err != nil {
+		return Set{}, false, err
+	}
+	return set, true, nil
+}
+
+// Resolve applies the set to the inherited list. The result is never nil,
+// so an explicitly emptied list stays distinguishable from an unset one.
+func (s Set) Resolve(inherited []string) []string {
+	out := make([]string, 0, len(inherited)+len(s.Include))
+	seen := map[string]bool{}
+	excluded := map[string]bool{}
+	for _, e := range s.Exclude {
+		excluded[e] = true
+	}
+	add := func(items []string) {
+		for _, item := range items {
+			if !seen[item] && !excluded[item] {
+				seen[item] = true
+				out = append(out, item)
+			}
+		}
+	}
+	if s.MergeDefaults {
+		add(inherited)
+	}
+	add(s.Include)
+	return out
 }
@@ -0,0 +1,164 @@
+package normalize
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/semantic"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+// ExpandMixins copies the fields and config of each model's included mixins
+// into the model, so later stages only ever see plain models. Mixin fields
+// follow the model's own; config the model sets wins over the mixin's, and
+// mixin indexes are added to the model's. Unknown mixins and fields defined
+// twice are reported and left out.
+func ExpandMixins(spec *symbols.ModelsSpec) []diagnostics.Diagnostic {
+	reporter := diagnostics.NewReporter()
+	if spec == nil {
+		return nil
+	}
+	mixins := map[string]*symbols.Mixin{}
+	for i := range spec.Mixins {
+		m := &spec.Mixins[i]
+		path := "mixin." + m.Name
+		if _, exists := mixins[m.Name]; exists {
+			reporter.Error("Duplicate mixin name: "+m.Name, diagnostics.Range{}, diagnostics.CodeInputDuplicate, path)
+			reporter.Relate(diagnostics.Related{Message: "First defined here", HclPath: path, First: true})
+			continue
+		}
+		mixins[m.Name] = m
+	}
+	if spec.ModelsBlock == nil {
+		return reporter.All()
+	}
+
+	for i := range spec.ModelsBlock.Models {
+		model := &spec.ModelsBlock.Models[i]
+		if len(model.Include) == 0 {
+			continue
+		}
+		modelPath := "models.model." + model.Name
+		owner := map[string]string{} // field name -> mixin that added it
+		own := map[string]bool{}
+		for _, f := range model.Fields {
+			own[f.Name] = true
+		}
+		seen := map[string]bool{}
+		for _, name := range model.Include {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			mixin, ok := mixins[name]
+			if !ok {
+				msg, related := unknownMixin(name, mixins)
+				reporter.Error("Model '"+model.Name+"' includes unknown mixin '"+name+"'"+msg, diagnostics.Range{}, diagnostics.CodeSchemaMixinNotFound, modelPath+".include")
+				reporter.Relate(related...)
+				continue
+			}
+			for _, f := range mixin.Fields {
+				fieldPath := "mixin." + name + ".field." + f.Name
+				switch {
+				case own[f.Name]:
+					reporter.Error("Field '"+f.Name+"' of model '"+model.Name+"' is also defined by mixin '"+name+"'.", diagnostics.Range{}, diagnostics.CodeSchemaMixinConflict, modelPath+".field."+f.Name)
+					reporter.Relate(diagnostics.Related{Message: "Mixin field defined here", HclPath: fieldPath})
+				case owner[f.Name] != "":
+					first := owner[f.Name]
+					reporter.Error("Mixins '"+first+"' and '"+name+"' included by model '"+model.Name+"' both define field '"+f.Name+"'.", diagnostics.Range{}, diagnostics.CodeSchemaMixinConflict, modelPath+".include")
+					reporter.Relate(
+						diagnostics.Related{Message: "Defined by '" + first + "' here", HclPath: "mixin." + first + ".field." + f.Name},
+						diagnostics.Related{Message: "Defined by '" + name + "' here", HclPath: fieldPath},
+					)
+				default:
+					owner[f.Name] = name
+					model.Fields = append(model.Fields, f)
+				}
+			}
+			if mixin.Config != nil {
+				if model.Config == nil {
+					model.Config = &symbols.ModelConfig{}
+				}
+				fillZero(reflect.ValueOf(model.Config).Elem(), reflect.ValueOf(mixin.Config).Elem())
+			}
+		}
+	}
+	return reporter.All()
+}
+
+// unknownMixin formats the did-you-mean suffix for an unknown mixin name.
+func unknownMixin(name string, mixins map[string]*symbols.Mixin) (string, []diagnostics.Related) {
+	candidates := make([]string, 0, len(mixins))
+	for k := range mixins {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	best := semantic.ClosestName(name, candidates)
+	if best == "" {
+		return ".", nil
+	}
+	return ". Did you mean '" + best + "'?", []diagnostics.Related{{
+		Message: "'" + best + "' is defined here",
+		HclPath: "mixin." + best,
+	}}
+}
+
+// fillZero sets the zero fields of dst from src, recursing into structs and
+// pointers and appending slices. Fields whose attribute the HCL body of dst
+// sets are kept, so an explicit `timestamps = false` is not overridden.
+// Pointers are copied, never shared, so a mixin's config stays untouched
+// for the next model that includes it.
+func fillZero(dst, src reflect.Value) {
+	switch dst.Kind() {
+	case reflect.Struct:
+		set := setAttributes(dst)
+		for i := 0; i < dst.NumField(); i++ {
+			f := dst.Type().Field(i)
+			if !f.IsExported() || f.Type == hclBodyType || set[strings.Split(f.Tag.Get("hcl"), ",")[0]] {
+				continue
+			}
+			fillZero(dst.Field(i), src.Field(i))
+		}
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		fillZero(dst.Elem(), src.Elem())
+	case reflect.Slice:
+		if src.Len() > 0 {
+			dst.Set(reflect.AppendSlice(reflect.MakeSlice(dst.Type(), 0, dst.Len()+src.Len()), dst))
+			dst.Set(reflect.AppendSlice(dst, src))
+		}
+	default:
+		if dst.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
+
+var hclBodyType = reflect.TypeOf((*hcl.Body)(nil)).Elem()
+
+// setAttributes returns the names of the attributes given in the HCL body
+// v was decoded from, kept in its `hcl:",body"` field. It is empty when v
+// has no such field or was not decoded from a file.
+func setAttributes(v reflect.Value) map[string]bool {
+	set := map[string]bool{}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Type != hclBodyType {
+			continue
+		}
+		if body, ok := v.Field(i).Interface().(*hclsyntax.Body); ok && body != nil {
+			for name := range body.Attributes {
+				set[name] = true
+			}
+		}
+	}
+	return set
+}
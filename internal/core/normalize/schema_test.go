@@ -0,0 +1,99 @@
+package normalize
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+const mixinSpec = `mixin "audited" {
+  field "createdBy" {
+    type = "string"
+  }
+  config {
+    timestamps = true
+    table      = "audited"
+  }
+}
+
+mixin "tenant" {
+  field "tenantId" {
+    type = "string"
+  }
+}
+
+mixin "owned" {
+  field "createdBy" {
+    type = "string"
+  }
+}
+
+models {
+  model "Post" {
+    include = ["audited", "tenant"]
+    field "title" {
+      type = "string"
+    }
+    config {
+      table = "posts"
+    }
+  }
+  model "Comment" {
+    include = ["audited"]
+    field "createdBy" {
+      type = "string"
+    }
+  }
+  model "Note" {
+    include = ["audited", "owned"]
+  }
+  model "Draft" {
+    include = ["audit"]
+  }
+}
+`
+
+func TestExpandMixins(t *testing.T) {
+	var spec symbols.ModelsSpec
+	if diags := ast.ParseFromHCLContent("models.hcl", mixinSpec, &spec); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %+v", diags)
+	}
+	diags := ExpandMixins(&spec)
+
+	want := []struct {
+		code    string
+		path    string
+		message string
+	}{
+		{diagnostics.CodeSchemaMixinConflict, "models.model.Comment.field.createdBy", "Field 'createdBy' of model 'Comment' is also defined by mixin 'audited'."},
+		{diagnostics.CodeSchemaMixinConflict, "models.model.Note.include", "Mixins 'audited' and 'owned' included by model 'Note' both define field 'createdBy'."},
+		{diagnostics.CodeSchemaMixinNotFound, "models.model.Draft.include", "Model 'Draft' includes unknown mixin 'audit'. Did you mean 'audited'?"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %+v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.Code != w.code || d.HclPath != w.path || d.Message != w.message {
+			t.Errorf("diagnostic %d = %s at %s: %q, want %s at %s: %q", i, d.Code, d.HclPath, d.Message, w.code, w.path, w.message)
+		}
+	}
+
+	post := spec.ModelsBlock.Models[0]
+	var fields []string
+	for _, f := range post.Fields {
+		fields = append(fields, f.Name)
+	}
+	if !slices.Equal(fields, []string{"title", "createdBy", "tenantId"}) {
+		t.Errorf("Post fields = %q, want [title createdBy tenantId]", fields)
+	}
+	if !post.Config.Timestamps || post.Config.Table != "posts" {
+		t.Errorf("Post config: timestamps %v, table %q; want the mixin's timestamps and the model's table", post.Config.Timestamps, post.Config.Table)
+	}
+	if spec.Mixins[0].Config.Table != "audited" {
+		t.Errorf("the mixin's config was modified: table %q", spec.Mixins[0].Config.Table)
+	}
+}
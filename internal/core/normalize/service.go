@@ -3,10 +3,19 @@ package normalize
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
+	"github.com/kwizyHQ/irex/internal/core/functions"
 	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/zclconf/go-cty/cty"
 )
 
+// crudOperations is the list a crud_operations set resolves against when no
+// enclosing defaults block gives one, so `except("delete")` works anywhere.
+var crudOperations = []string{"create", "read", "update", "delete", "list"}
+
+var ctyValueType = reflect.TypeOf(cty.Value{})
+
 func SafeSet(parentField, childField reflect.Value) {
 	if !childField.CanSet() {
 		return
@@ -113,6 +122,10 @@ func NormalizeServiceAST(def *symbols.ServiceDefinition) {
 		}
 	}
 
+	if def.Services.Defaults != nil {
+		ResolveDefaultSets(def.Services.Defaults, nil)
+	}
+
 	// now we need to walk the service tree and apply defaults recursively
 	var walk func(svcs []symbols.Service, parentDefaults *symbols.ServiceDefaults)
 	walk = func(svcs []symbols.Service, parentDefaults *symbols.ServiceDefaults) {
@@ -122,9 +135,12 @@ func NormalizeServiceAST(def *symbols.ServiceDefinition) {
 			if svc.Defaults == nil {
 				svc.Defaults = &symbols.ServiceDefaults{}
 			}
-			// Step 1: Merge parent defaults into current service
+			// Step 1: Resolve set attributes against the parent, then merge
+			// parent defaults into current service
+			ResolveDefaultSets(svc.Defaults, parentDefaults)
 			MergeDefaults(parentDefaults, svc.Defaults)
 			// Step 2: Apply explicit service overrides
+			ResolveServiceSets(svc, svc.Defaults)
 			MergeFromDefaults(svc.Defaults, svc)
 
 			// Step 3: Recurse into child services
@@ -136,3 +152,132 @@ func NormalizeServiceAST(def *symbols.ServiceDefinition) {
 	walk(def.Services.Services, def.Services.Defaults)
 
 }
+
+// ResolveDefaultSets resolves the list attributes of d that were given as a
+// list or set function against the resolved values of parent (may be nil).
+func ResolveDefaultSets(d, parent *symbols.ServiceDefaults) {
+	var p symbols.ServiceDefaults
+	if parent != nil {
+		p = *parent
+	}
+	d.CrudOperations = resolveSet(d.CrudOperationsSet, d.CrudOperations, crudBase(p.CrudOperations))
+	d.BatchOperations = resolveSet(d.BatchOperationsSet, d.BatchOperations, p.BatchOperations)
+	d.Middlewares = resolveSet(d.MiddlewaresSet, d.Middlewares, p.Middlewares)
+	d.Sorting = resolveSet(d.SortingSet, d.Sorting, p.Sorting)
+	d.Filtering = resolveSet(d.FilteringSet, d.Filtering, p.Filtering)
+}
+
+// ResolveServiceSets does the same for the attributes set on svc itself,
+// against its (already resolved) defaults.
+func ResolveServiceSets(svc *symbols.Service, defaults *symbols.ServiceDefaults) {
+	var d symbols.ServiceDefaults
+	if defaults != nil {
+		d = *defaults
+	}
+	svc.CrudOperations = resolveSet(svc.CrudOperationsSet, svc.CrudOperations, crudBase(d.CrudOperations))
+	svc.BatchOperations = resolveSet(svc.BatchOperationsSet, svc.BatchOperations, d.BatchOperations)
+	svc.Middlewares = resolveSet(svc.MiddlewaresSet, svc.Middlewares, d.Middlewares)
+}
+
+// resolveSet applies the set in v to inherited, keeping current when v was
+// not given. Invalid values are reported by validate.
+func resolveSet(v cty.Value, current, inherited []string) []string {
+	set, ok, err := functions.DecodeSet(v)
+	if !ok || err != nil {
+		return current
+	}
+	return set.Resolve(inherited)
+}
+
+// crudBase expands "*" in an inherited crud_operations list, defaulting to
+// every CRUD operation.
+func crudBase(inherited []string) []string {
+	if inherited == nil {
+		return crudOperations
+	}
+	out := make([]string, 0, len(inherited))
+	for _, op := range inherited {
+		if op == "*" {
+			out = append(out, crudOperations...)
+			continue
+		}
+		out = append(out, strings.ToLower(op))
+	}
+	return out
+}
+
+// Inherited is a service setting that NormalizeServiceAST fills in from an
+// enclosing defaults block rather than from the service itself.
+type Inherited struct {
+	Name  string // hcl attribute name on the service
+	Value any    // dereferenced default value
+}
+
+// InheritedDefaults reports the settings each service inherits, without
+// modifying def. Services are keyed by their block path as used in
+// diagnostics, e.g. "services.service.users.service.profile".
+func InheritedDefaults(def *symbols.ServiceDefinition) map[string][]Inherited {
+	out := make(map[string][]Inherited)
+	if def == nil || def.Services == nil {
+		return out
+	}
+
+	var walk func(svcs []symbols.Service, parentDefaults *symbols.ServiceDefaults, prefix string)
+	walk = func(svcs []symbols.Service, parentDefaults *symbols.ServiceDefaults, prefix string) {
+		for i := range svcs {
+			svc := &svcs[i]
+			path := prefix + ".service." + svc.Name
+
+			// same merge as NormalizeServiceAST, on a copy
+			effective := symbols.ServiceDefaults{}
+			if svc.Defaults != nil {
+				effective = *svc.Defaults
+			}
+			ResolveDefaultSets(&effective, parentDefaults)
+			if parentDefaults != nil {
+				MergeDefaults(parentDefaults, &effective)
+			}
+
+			srcVal := reflect.ValueOf(effective)
+			tgtVal := reflect.ValueOf(svc).Elem()
+			for f := 0; f < srcVal.NumField(); f++ {
+				srcField := srcVal.Field(f)
+				if srcField.IsZero() || srcField.Type() == ctyValueType {
+					continue
+				}
+				name := srcVal.Type().Field(f).Name
+				if _, ok := tgtVal.Type().FieldByName(name); !ok {
+					continue
+				}
+				// resolved lists are set through their *Set attribute
+				field, ok := tgtVal.Type().FieldByName(name + "Set")
+				if !ok {
+					field, _ = tgtVal.Type().FieldByName(name)
+				}
+				if given := tgtVal.FieldByIndex(field.Index); given.Type() == ctyValueType {
+					if !given.Interface().(cty.Value).IsNull() {
+						continue
+					}
+				} else if !given.IsZero() {
+					continue
+				}
+				out[path] = append(out[path], Inherited{
+					Name:  strings.Split(field.Tag.Get("hcl"), ",")[0],
+					Value: reflect.Indirect(srcField).Interface(),
+				})
+			}
+
+			if len(svc.Services) > 0 {
+				walk(svc.Services, &effective, path)
+			}
+		}
+	}
+	var top *symbols.ServiceDefaults
+	if def.Services.Defaults != nil {
+		d := *def.Services.Defaults
+		ResolveDefaultSets(&d, nil)
+		top = &d
+	}
+	walk(def.Services.Services, top, "services")
+	return out
+}
@@ -0,0 +1,160 @@
+package overlay
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FS is the read-only file access used by the pipeline. It mirrors the
+// fs.ReadFileFS / fs.StatFS / fs.GlobFS trio, but works on native OS paths
+// (absolute or relative to the working directory) instead of slash paths.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (fs.FileInfo, error)
+	Glob(pattern string) ([]string, error)
+}
+
+type osFS struct{}
+
+func (osFS) ReadFile(name string) ([]byte, error)  { return os.ReadFile(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+func (osFS) Glob(pattern string) ([]string, error) { return filepath.Glob(pattern) }
+
+// OS reads directly from disk.
+var OS FS = osFS{}
+
+// Or returns fsys, falling back to OS when fsys is nil.
+func Or(fsys FS) FS {
+	if fsys == nil {
+		return OS
+	}
+	return fsys
+}
+
+// Overlay is an FS that serves in-memory buffers (e.g. unsaved editor
+// documents) and falls back to a base FS for everything else.
+// Overlay is safe for concurrent use.
+type Overlay struct {
+	mu    sync.RWMutex
+	base  FS
+	files map[string]memFile
+}
+
+type memFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// New returns an empty overlay on top of base (OS when nil).
+func New(base FS) *Overlay {
+	return &Overlay{
+		base:  Or(base),
+		files: make(map[string]memFile),
+	}
+}
+
+// key normalises a path so that relative and absolute spellings of the same
+// file resolve to one buffer.
+func key(name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return filepath.Clean(name)
+}
+
+// Set stores content for name, shadowing the file on the base FS.
+func (o *Overlay) Set(name string, content []byte) {
+	o.mu.Lock()
+	o.files[key(name)] = memFile{data: content, modTime: time.Now()}
+	o.mu.Unlock()
+}
+
+// Delete drops the buffer for name so reads fall through to the base FS again.
+func (o *Overlay) Delete(name string) {
+	o.mu.Lock()
+	delete(o.files, key(name))
+	o.mu.Unlock()
+}
+
+// Has reports whether name is served from memory.
+func (o *Overlay) Has(name string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	_, ok := o.files[key(name)]
+	return ok
+}
+
+// Paths returns the absolute paths of all buffered files, sorted.
+func (o *Overlay) Paths() []string {
+	o.mu.RLock()
+	out := make([]string, 0, len(o.files))
+	for k := range o.files {
+		out = append(out, k)
+	}
+	o.mu.RUnlock()
+	sort.Strings(out)
+	return out
+}
+
+func (o *Overlay) ReadFile(name string) ([]byte, error) {
+	o.mu.RLock()
+	f, ok := o.files[key(name)]
+	o.mu.RUnlock()
+	if ok {
+		out := make([]byte, len(f.data))
+		copy(out, f.data)
+		return out, nil
+	}
+	return o.base.ReadFile(name)
+}
+
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	o.mu.RLock()
+	f, ok := o.files[key(name)]
+	o.mu.RUnlock()
+	if ok {
+		return memFileInfo{name: filepath.Base(name), size: int64(len(f.data)), modTime: f.modTime}, nil
+	}
+	return o.base.Stat(name)
+}
+
+// Glob returns the base matches plus any buffered files matching pattern
+// that do not exist on the base FS yet (new, unsaved documents).
+func (o *Overlay) Glob(pattern string) ([]string, error) {
+	matches, err := o.base.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(matches))
+	for _, m := range matches {
+		seen[key(m)] = struct{}{}
+	}
+	absPattern := key(pattern)
+	for _, p := range o.Paths() {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		if ok, _ := filepath.Match(absPattern, p); ok {
+			matches = append(matches, p)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return 0644 }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
@@ -1,11 +1,17 @@
 package pipeline
 
 import (
+	"os"
 	"path/filepath"
+	"slices"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/kwizyHQ/irex/internal/core/assemble"
 	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/functions"
 	"github.com/kwizyHQ/irex/internal/core/normalize"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/resolve"
 	"github.com/kwizyHQ/irex/internal/core/semantic"
 	"github.com/kwizyHQ/irex/internal/core/shared"
 	"github.com/kwizyHQ/irex/internal/core/symbols"
@@ -15,10 +21,26 @@ import (
 
 type BuildOptions struct {
 	ConfigPath string
+	// FS is used for every file read and glob. Nil means the OS filesystem;
+	// the LSP passes an overlay so unsaved buffers are validated.
+	FS overlay.FS
+	// Vars overrides the defaults of the variables block (--var, --var-file).
+	Vars resolve.VarOptions
+	// Profile selects the profile blocks merged onto the config and service
+	// specs (--profile). Empty falls back to IREX_PROFILE.
+	Profile string
 }
 
 func Build(opts BuildOptions) (*shared.IRBundle, diagnostics.Diagnostics) {
+	return build(opts, nil)
+}
+
+// build is Build that also records, in fc when it is not nil, the context
+// single files are validated in. The schema part is only filled in when the
+// build gets as far as decoding the schema files.
+func build(opts BuildOptions, fc *FileContext) (*shared.IRBundle, diagnostics.Diagnostics) {
 	r := diagnostics.NewReporter()
+	fsys := overlay.Or(opts.FS)
 	ctx := &shared.BuildContext{
 		ConfigAST: &shared.ConfigAST{},
 		SchemaAST: &shared.SchemaAST{
@@ -30,49 +52,107 @@ func Build(opts BuildOptions) (*shared.IRBundle, diagnostics.Diagnostics) {
 		IR:          &shared.IRBundle{},
 	}
 
+	// diagnostics without a more specific origin belong to the config file
+	r.SetFilename(opts.ConfigPath)
+
+	// ------------------- Variables ----------------
+	vars, varDiags := resolve.LoadVariables(fsys, opts.ConfigPath, opts.Vars)
+	r.Extend(withFilename(varDiags, opts.ConfigPath))
+	decodeOpts := ast.Options{Eval: functions.EvalContext(vars), Profile: ActiveProfile(opts.Profile)}
+	ctx.Profile = decodeOpts.Profile
+
 	// ------------------- Config AST Decode ----------------
-	r.Extend(ast.ParseHCL(opts.ConfigPath, ctx.ConfigAST))
+	r.ExtendWithFilename(ast.ParseHCLFSWith(fsys, opts.ConfigPath, ctx.ConfigAST, decodeOpts))
 
-	r.Extend(
+	// severity overrides and inline suppressions apply to everything reported
+	lint := LintFromConfig(ctx.ConfigAST)
+	applyFileIgnores(r, lint, fsys, opts.ConfigPath)
+	r.SetLint(lint)
+	if fc != nil {
+		*fc = FileContext{Lint: lint, Eval: decodeOpts.Eval, Profile: decodeOpts.Profile, FS: fsys}
+	}
+
+	r.ExtendWithFilename(
 		validate.ValidateConfig(ctx.ConfigAST),
 	)
+	ctx.Paths = ResolvePaths(opts.ConfigPath, ctx.ConfigAST)
+	r.ExtendWithFilename(CheckPaths(fsys, ctx.Paths))
 
 	if r.HasErrors() {
 		return nil, r.All()
 	}
 
+	// ---------------- Env resolution ----------------
+	env, err := resolve.LoadEnv(fsys, opts.ConfigPath)
+	if err != nil {
+		r.Warn("Could not read the project's env file: "+err.Error(), diagnostics.Range{}, diagnostics.CodeConfigReadError, "project")
+	}
+	r.ExtendWithFilename(resolve.CheckConfig(ctx.ConfigAST, env))
+
 	// ---------------- Other AST Decode ----------------
-	schemaPath := filepath.Join(ctx.ConfigAST.Project.Paths.Specifications, "schema")
-	files, _ := filepath.Glob(filepath.Join(schemaPath, "*.hcl"))
+	specDir := ctx.Paths.Specifications
+	schemaPath := filepath.Join(specDir, "schema")
+	schemaFiles, _ := fsys.Glob(filepath.Join(schemaPath, "*.hcl"))
+	applyFileIgnores(r, lint, fsys, schemaFiles...)
 
+	locals, localDiags := resolve.LoadLocals(fsys, schemaFiles, decodeOpts.Eval)
+	schemaOpts := decodeOpts
+	schemaOpts.Eval = functions.WithLocals(decodeOpts.Eval, locals)
+	r.Extend(localDiags)
+	// fields set from a failed local would only repeat the error
+	if r.HasErrors() {
+		return nil, r.All()
+	}
 	var schemaContainsError bool
-	for _, path := range files {
+	mixins := map[string][]symbols.Mixin{}
+	for _, path := range schemaFiles {
 		var spec symbols.ModelsSpec
-		// We assume ParseHCL now handles the pointer internally
-		if diags := ast.ParseHCL(path, &spec); len(diags) > 0 {
+		diags := ast.ParseHCLFSWith(fsys, path, &spec, schemaOpts)
+		if len(spec.Mixins) > 0 {
+			mixins[path] = spec.Mixins
+		}
+		if len(diags) > 0 {
 			schemaContainsError = true
 			r.Extend(diags)
 			continue
 		}
 		// Direct append using the spread operator (...)
+		ctx.SchemaAST.Mixins = append(ctx.SchemaAST.Mixins, spec.Mixins...)
 		if spec.ModelsBlock != nil {
 			ctx.SchemaAST.ModelsBlock.Models = append(ctx.SchemaAST.ModelsBlock.Models, spec.ModelsBlock.Models...)
 		}
 	}
 
+	if fc != nil {
+		fc.Locals, fc.Mixins = locals, mixins
+	}
 	if schemaContainsError {
 		return nil, r.All()
 	}
+	r.Extend(locateInFiles(fsys, normalize.ExpandMixins(ctx.SchemaAST), schemaFiles))
 
-	servicesPath := filepath.Join(ctx.ConfigAST.Project.Paths.Specifications, "service")
-	files, err := filepath.Glob(filepath.Join(servicesPath, "*.hcl"))
-	if err != nil || len(files) == 0 {
-		r.Error("Warning we couln't found any service files, please add some.", diagnostics.Range{}, "service.read_error", "pipeline")
+	servicesPath := filepath.Join(specDir, "service")
+	serviceFiles, err := fsys.Glob(filepath.Join(servicesPath, "*.hcl"))
+	if err != nil || len(serviceFiles) == 0 {
+		r.Error("Warning we couln't found any service files, please add some.", diagnostics.Range{}, diagnostics.CodeServiceRead, "pipeline")
 		return nil, r.All()
 	}
+	serviceFile := serviceFiles[0]
+	applyFileIgnores(r, lint, fsys, serviceFile)
 	r.Extend(
-		ast.ParseHCL(files[0], ctx.ServicesAST),
+		ast.ParseHCLFSWith(fsys, serviceFile, ctx.ServicesAST, decodeOpts),
 	)
+	if p := decodeOpts.Profile; p != "" && !declaresProfile(fsys, p, opts.ConfigPath, serviceFile) {
+		r.Error("Profile '"+p+"' is not declared in irex.hcl or the service specs.", diagnostics.Range{},
+			diagnostics.CodeConfigProfileUnknown, "profile")
+	}
+
+	// ---------------- Modules ----------------
+	modules, moduleDiags := LoadModules(fsys, opts.ConfigPath, ctx.ConfigAST, decodeOpts)
+	for _, m := range modules {
+		applyFileIgnores(r, lint, fsys, m.Files...)
+	}
+	r.Extend(moduleDiags)
 
 	// if reporter.HasErrors() {
 	// 	return nil, reporter.All()
@@ -106,19 +186,21 @@ func Build(opts BuildOptions) (*shared.IRBundle, diagnostics.Diagnostics) {
 	// ---------------- Validations ----------------
 
 	r.Extend(
-		validate.ValidateService(ctx.ServicesAST),
+		withFilename(validate.ValidateService(ctx.ServicesAST), serviceFile),
 	)
 	r.Extend(
-		validate.ValidateSchema(ctx.SchemaAST),
+		locateInFiles(fsys, validate.ValidateSchema(ctx.SchemaAST), schemaFiles),
 	)
 
 	if r.HasErrors() {
 		return nil, r.All()
 	}
 
+	r.Extend(withFilename(mergeModules(ctx, modules), opts.ConfigPath))
+
 	// ---------------- Cross Validation: Semantic checks ----------------
 	// Validate that all service model references exist in schema
-	r.Extend(semantic.CheckServiceSemantic(ctx.ServicesAST, ctx.SchemaAST))
+	r.Extend(locateInFiles(fsys, withFilename(semantic.CheckServiceSemantic(ctx.ServicesAST, ctx.SchemaAST), serviceFile), schemaFiles))
 
 	if r.HasErrors() {
 		return nil, r.All()
@@ -132,8 +214,176 @@ func Build(opts BuildOptions) (*shared.IRBundle, diagnostics.Diagnostics) {
 	err = assemble.ProjectIR(ctx)
 
 	if err != nil {
-		r.Error("IR Build error: "+err.Error(), diagnostics.Range{}, "ir.build_error", "pipeline")
+		r.Error("IR Build error: "+err.Error(), diagnostics.Range{}, diagnostics.CodeIRBuild, "pipeline")
 	}
 
 	return ctx.IR, r.All()
 }
+
+// ActiveProfile is the profile a build uses: flag when set, else IREX_PROFILE.
+func ActiveProfile(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return os.Getenv("IREX_PROFILE")
+}
+
+// declaresProfile reports whether any of files has a profile block named name.
+func declaresProfile(fsys overlay.FS, name string, files ...string) bool {
+	for _, fn := range files {
+		src, err := fsys.ReadFile(fn)
+		if err == nil && slices.Contains(ast.Profiles(fn, src), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withFilename stamps fn on every diagnostic that has no filename yet.
+func withFilename(diags []diagnostics.Diagnostic, fn string) []diagnostics.Diagnostic {
+	for i := range diags {
+		if diags[i].Filename == "" {
+			diags[i].Filename = fn
+		}
+	}
+	return diags
+}
+
+// locateInFiles attributes diagnostics produced from a merged AST back to the
+// spec file that defines their HclPath, and does the same for related
+// locations. Diagnostics whose path is not found in any file fall back to the
+// first file; a duplicate is placed in the last file defining it, since its
+// first definition is the related location.
+func locateInFiles(fsys overlay.FS, diags []diagnostics.Diagnostic, files []string) []diagnostics.Diagnostic {
+	if len(files) == 0 {
+		return diags
+	}
+	tables := make([]SymbolTable, len(files))
+	for i, fn := range files {
+		tables[i], _ = WalkHCLSymbolsFS(fsys, fn)
+	}
+	find := func(path string, last bool) string {
+		found := ""
+		for j, t := range tables {
+			if t.Attrs[path] != nil || t.Blocks[path] != nil {
+				found = files[j]
+				if !last {
+					break
+				}
+			}
+		}
+		return found
+	}
+	for i, d := range diags {
+		if d.Filename == "" {
+			diags[i].Filename = files[0]
+			if fn := find(d.HclPath, isDuplicate(d)); fn != "" {
+				diags[i].Filename = fn
+			}
+		}
+		if len(d.Related) == 0 {
+			continue
+		}
+		related := make([]diagnostics.Related, len(d.Related))
+		for j, r := range d.Related {
+			if r.Filename == "" && r.HclPath != "" {
+				r.Filename = find(r.HclPath, false)
+			}
+			related[j] = r
+		}
+		diags[i].Related = related
+	}
+	return diags
+}
+
+// isDuplicate reports whether d points back at an earlier definition of its own path.
+func isDuplicate(d diagnostics.Diagnostic) bool {
+	for _, r := range d.Related {
+		if r.First && r.HclPath == d.HclPath {
+			return true
+		}
+	}
+	return false
+}
+
+// specificationsDir is the resolved paths.specifications, or the project
+// root when unset.
+func specificationsDir(configPath string, cfg *shared.ConfigAST) string {
+	paths := ResolvePaths(configPath, cfg)
+	if paths.Specifications != "" {
+		return paths.Specifications
+	}
+	return paths.Root
+}
+
+// SpecDir returns the specifications directory of the project at
+// configPath, or "" when its irex.hcl does not parse.
+func SpecDir(configPath string, fsys overlay.FS) string {
+	fsys = overlay.Or(fsys)
+	cfg := &shared.ConfigAST{}
+	if diags := ast.ParseHCLFSWith(fsys, configPath, cfg, ProjectDecodeOptions(configPath, fsys)); len(diags) > 0 && cfg.Project == nil {
+		return ""
+	}
+	return specificationsDir(configPath, cfg)
+}
+
+// SpecFiles lists the schema and service spec files of the project at configPath.
+func SpecFiles(configPath string, fsys overlay.FS) (schemaFiles []string, serviceFiles []string) {
+	specDir := SpecDir(configPath, fsys)
+	if specDir == "" {
+		return nil, nil
+	}
+	fsys = overlay.Or(fsys)
+	schemaFiles, _ = fsys.Glob(filepath.Join(specDir, "schema", "*.hcl"))
+	serviceFiles, _ = fsys.Glob(filepath.Join(specDir, "service", "*.hcl"))
+	return schemaFiles, serviceFiles
+}
+
+// LoadModels returns the project's models, including those imported from its
+// modules, that decoded cleanly. It is meant for editor features that need
+// the model list even while other files have errors.
+func LoadModels(configPath string, fsys overlay.FS) []symbols.Model {
+	schemaFiles, _ := SpecFiles(configPath, fsys)
+	decodeOpts := ProjectDecodeOptions(configPath, fsys)
+	schemaOpts, _ := schemaDecodeOptions(fsys, schemaFiles, decodeOpts)
+	all := symbols.ModelsSpec{ModelsBlock: &symbols.ModelsBlock{}}
+	for _, path := range schemaFiles {
+		var spec symbols.ModelsSpec
+		ast.ParseHCLFSWith(fsys, path, &spec, schemaOpts)
+		all.Mixins = append(all.Mixins, spec.Mixins...)
+		if spec.ModelsBlock != nil {
+			all.ModelsBlock.Models = append(all.ModelsBlock.Models, spec.ModelsBlock.Models...)
+		}
+	}
+	normalize.ExpandMixins(&all)
+	models := all.ModelsBlock.Models
+	cfg := &shared.ConfigAST{}
+	ast.ParseHCLFSWith(fsys, configPath, cfg, decodeOpts)
+	modules, _ := LoadModules(fsys, configPath, cfg, decodeOpts)
+	for _, m := range modules {
+		models = append(models, m.Models...)
+	}
+	return models
+}
+
+// ProjectEvalContext is the context the project at configPath is decoded
+// with, its variables at their defaults. Problems with the variables are
+// left for Build to report.
+func ProjectEvalContext(configPath string, fsys overlay.FS) *hcl.EvalContext {
+	vars, _ := resolve.LoadVariables(fsys, configPath, resolve.VarOptions{})
+	return functions.EvalContext(vars)
+}
+
+// ProjectDecodeOptions are the options editor features decode the project's
+// files with: ProjectEvalContext and the IREX_PROFILE profile.
+func ProjectDecodeOptions(configPath string, fsys overlay.FS) ast.Options {
+	return ast.Options{Eval: ProjectEvalContext(configPath, fsys), Profile: ActiveProfile("")}
+}
+
+// schemaDecodeOptions returns opts with the locals of the schema files in
+// scope, along with any problems evaluating them.
+func schemaDecodeOptions(fsys overlay.FS, files []string, opts ast.Options) (ast.Options, []diagnostics.Diagnostic) {
+	locals, diags := resolve.LoadLocals(fsys, files, opts.Eval)
+	opts.Eval = functions.WithLocals(opts.Eval, locals)
+	return opts, diags
+}
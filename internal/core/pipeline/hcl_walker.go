@@ -1,16 +1,26 @@
 package pipeline
 
 import (
-	"os"
-
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
 )
 
 // SymbolTable holds discovered attributes and blocks in an HCL file.
 type SymbolTable struct {
 	Attrs  map[string]*AttrSource
-	Blocks map[string]*BlockSource
+	Blocks map[string]*BlockSource // the last block at each path
+	// FirstBlocks holds the first block at each path; it differs from
+	// Blocks only where a path is defined more than once.
+	FirstBlocks map[string]*BlockSource
+}
+
+func newSymbolTable() SymbolTable {
+	return SymbolTable{
+		Attrs:       make(map[string]*AttrSource),
+		Blocks:      make(map[string]*BlockSource),
+		FirstBlocks: make(map[string]*BlockSource),
+	}
 }
 
 // AttrSource represents the source information for an HCL attribute.
@@ -26,25 +36,42 @@ type AttrSource struct {
 type BlockSource struct {
 	Path      string
 	File      string
+	Type      string
+	Labels    []string
 	DefRange  hcl.Range
 	BodyRange hcl.Range
+	// NameRange covers the last label, or the block type when unlabeled.
+	NameRange hcl.Range
 }
 
 // WalkHCLSymbols parses the given HCL file and returns a SymbolTable of all attributes and blocks.
 func WalkHCLSymbols(filePath string) (SymbolTable, error) {
-	var symbolsMap SymbolTable
-	symbolsMap.Attrs = make(map[string]*AttrSource)
-	symbolsMap.Blocks = make(map[string]*BlockSource)
+	return WalkHCLSymbolsFS(overlay.OS, filePath)
+}
 
-	content, err := os.ReadFile(filePath)
+// WalkHCLSymbolsFS is WalkHCLSymbols reading the file through fsys.
+func WalkHCLSymbolsFS(fsys overlay.FS, filePath string) (SymbolTable, error) {
+	content, err := overlay.Or(fsys).ReadFile(filePath)
 	if err != nil {
-		return symbolsMap, err
+		return newSymbolTable(), err
 	}
+	return WalkHCLSource(filePath, content)
+}
+
+// WalkHCLSource builds the SymbolTable from in-memory HCL source.
+func WalkHCLSource(filePath string, content []byte) (SymbolTable, error) {
+	symbolsMap := newSymbolTable()
+
 	configFile, parseErr := hclsyntax.ParseConfig(content, filePath, hcl.Pos{Line: 1, Column: 1})
-	if parseErr != nil {
+	if configFile == nil {
 		return symbolsMap, parseErr
 	}
+	// walk whatever parsed so editors keep an outline while the user types,
+	// but still report the syntax errors to the caller
 	walkBody(configFile.Body.(*hclsyntax.Body), "", filePath, &symbolsMap)
+	if parseErr.HasErrors() {
+		return symbolsMap, parseErr
+	}
 	return symbolsMap, nil
 }
 
@@ -81,11 +108,23 @@ func walkBody(
 			blockPath += "." + label
 		}
 
-		symbols.Blocks[blockPath] = &BlockSource{
+		nameRange := block.TypeRange
+		if len(block.LabelRanges) > 0 {
+			nameRange = block.LabelRanges[len(block.LabelRanges)-1]
+		}
+
+		src := &BlockSource{
 			Path:      blockPath,
 			File:      file,
+			Type:      block.Type,
+			Labels:    block.Labels,
 			DefRange:  block.Range(),
 			BodyRange: block.Body.Range(),
+			NameRange: nameRange,
+		}
+		symbols.Blocks[blockPath] = src
+		if _, seen := symbols.FirstBlocks[blockPath]; !seen {
+			symbols.FirstBlocks[blockPath] = src
 		}
 
 		walkBody(block.Body, blockPath, file, symbols)
@@ -0,0 +1,188 @@
+package pipeline
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+const ignoreDirective = "irex:ignore"
+
+// LintFromConfig builds the Lint for the `lint` block of cfg. Invalid
+// severities are skipped here; ValidateConfig reports them.
+func LintFromConfig(cfg *shared.ConfigAST) *diagnostics.Lint {
+	l := diagnostics.NewLint()
+	if cfg == nil || cfg.Lint == nil {
+		return l
+	}
+	for code, value := range cfg.Lint.Severity {
+		if s, err := diagnostics.ParseSeverity(value); err == nil {
+			l.SetSeverity(code, s)
+		}
+	}
+	return l
+}
+
+// LoadLint reads the lint settings of the config at configPath. It never
+// fails: a config that does not decode simply has no overrides.
+func LoadLint(configPath string, fsys overlay.FS) *diagnostics.Lint {
+	cfg := &shared.ConfigAST{}
+	if configPath != "" {
+		_ = ast.ParseHCLFSWith(overlay.Or(fsys), configPath, cfg, ProjectDecodeOptions(configPath, fsys))
+	}
+	return LintFromConfig(cfg)
+}
+
+// IgnoresFromSource collects the `# irex:ignore code[, ...]` comments of an
+// HCL file. A comment on its own line covers the block or attribute that
+// starts on the next line (everything nested in it included); a trailing
+// comment covers its own line. A comment must name the codes it ignores;
+// one without codes, and codes that are errors, are reported instead.
+func IgnoresFromSource(filename string, src []byte) ([]diagnostics.Ignore, []diagnostics.Diagnostic) {
+	tokens, _ := hclsyntax.LexConfig(src, filename, hcl.InitialPos)
+	var out []diagnostics.Ignore
+	var diags []diagnostics.Diagnostic
+	warn := func(tok hclsyntax.Token, message string) {
+		// a line comment ends with its newline; keep the range on its line
+		rng := tok.Range
+		if text := strings.TrimRight(string(tok.Bytes), "\r\n"); len(text) < len(tok.Bytes) {
+			rng.End = hcl.Pos{Line: rng.Start.Line, Column: rng.Start.Column + len(text), Byte: rng.Start.Byte + len(text)}
+		}
+		diags = append(diags, diagnostics.Diagnostic{
+			Range:    toRange(rng),
+			Severity: diagnostics.SeverityWarning,
+			Message:  message,
+			Filename: filename,
+			Code:     diagnostics.CodeInputIgnored,
+		})
+	}
+	var table *SymbolTable
+	for i, tok := range tokens {
+		if tok.Type != hclsyntax.TokenComment {
+			continue
+		}
+		all, ok := parseIgnore(string(tok.Bytes))
+		if !ok {
+			continue
+		}
+		if len(all) == 0 {
+			warn(tok, "irex:ignore needs the codes it ignores, e.g. '# irex:ignore "+diagnostics.CodeInputRecommended+"'.")
+			continue
+		}
+		var codes []string
+		for _, code := range all {
+			if _, known := diagnostics.LookupCode(code); known && !diagnostics.Suppressible(code) {
+				warn(tok, "'"+code+"' is an error and cannot be ignored.")
+				continue
+			}
+			codes = append(codes, code)
+		}
+		if len(codes) == 0 {
+			continue
+		}
+		line := tok.Range.Start.Line
+		if !trailingComment(tokens, i) {
+			line = nextCodeLine(tokens, i)
+			if line == 0 {
+				continue
+			}
+		}
+		if table == nil {
+			t, _ := WalkHCLSource(filename, src)
+			table = &t
+		}
+		out = append(out, ignoreAt(table, line, codes))
+	}
+	return out, diags
+}
+
+// parseIgnore returns the codes named by an irex:ignore comment.
+func parseIgnore(comment string) ([]string, bool) {
+	text := strings.TrimSpace(comment)
+	switch {
+	case strings.HasPrefix(text, "#"):
+		text = text[1:]
+	case strings.HasPrefix(text, "//"):
+		text = text[2:]
+	case strings.HasPrefix(text, "/*"):
+		text = strings.TrimSuffix(text[2:], "*/")
+	}
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, ignoreDirective) {
+		return nil, false
+	}
+	rest := text[len(ignoreDirective):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return nil, false
+	}
+	codes := strings.FieldsFunc(rest, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	return codes, true
+}
+
+// trailingComment reports whether the comment at i follows code on its line.
+func trailingComment(tokens hclsyntax.Tokens, i int) bool {
+	line := tokens[i].Range.Start.Line
+	for j := i - 1; j >= 0; j-- {
+		t := tokens[j]
+		if t.Range.End.Line < line || (t.Type == hclsyntax.TokenNewline && t.Range.Start.Line < line) {
+			return false
+		}
+		if t.Type != hclsyntax.TokenComment && t.Type != hclsyntax.TokenNewline {
+			return true
+		}
+	}
+	return false
+}
+
+// nextCodeLine returns the line of the first token after i that is not a
+// comment or newline, or 0 at end of file.
+func nextCodeLine(tokens hclsyntax.Tokens, i int) int {
+	for _, t := range tokens[i+1:] {
+		switch t.Type {
+		case hclsyntax.TokenComment, hclsyntax.TokenNewline:
+			continue
+		case hclsyntax.TokenEOF:
+			return 0
+		}
+		return t.Range.Start.Line
+	}
+	return 0
+}
+
+// ignoreAt scopes an ignore to the outermost block or attribute starting on line.
+func ignoreAt(table *SymbolTable, line int, codes []string) diagnostics.Ignore {
+	ig := diagnostics.Ignore{Codes: codes, StartLine: line, EndLine: line}
+	consider := func(path string, rng hcl.Range) {
+		if rng.Start.Line != line {
+			return
+		}
+		if ig.Path == "" || strings.Count(path, ".") < strings.Count(ig.Path, ".") {
+			ig.Path = path
+			ig.EndLine = rng.End.Line
+		}
+	}
+	for path, b := range table.Blocks {
+		consider(path, b.DefRange)
+	}
+	for path, a := range table.Attrs {
+		consider(path, a.DefRange)
+	}
+	return ig
+}
+
+// applyFileIgnores registers the inline suppressions of each file with l and
+// reports the comments it could not apply to r.
+func applyFileIgnores(r *diagnostics.Reporter, l *diagnostics.Lint, fsys overlay.FS, files ...string) {
+	for _, fn := range files {
+		if src, err := fsys.ReadFile(fn); err == nil {
+			ignores, diags := IgnoresFromSource(fn, src)
+			l.SetIgnores(fn, ignores)
+			r.Extend(diags)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/core/validate"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+const lintConfig = `# irex:ignore irex.input.recommended
+project {
+  name    = "demo"
+  version = "1.0.0"
+}
+
+# irex:ignore irex.input.required
+models {
+}
+`
+
+func TestIgnoresFromSource(t *testing.T) {
+	ignores, diags := IgnoresFromSource("irex.hcl", []byte(lintConfig))
+	if len(ignores) != 1 || ignores[0].Path != "project" {
+		t.Fatalf("want one ignore covering project, got %+v", ignores)
+	}
+	if len(diags) != 1 || diags[0].Code != diagnostics.CodeInputIgnored || diags[0].Range.Start.Line != 7 {
+		t.Fatalf("want the error code rejected on line 7, got %+v", diags)
+	}
+
+	lint := diagnostics.NewLint()
+	lint.SetIgnores("irex.hcl", ignores)
+	got := lint.Apply([]diagnostics.Diagnostic{
+		{Severity: diagnostics.SeverityWarning, Code: diagnostics.CodeInputRecommended, HclPath: "project.author", Filename: "irex.hcl"},
+		{Severity: diagnostics.SeverityError, Code: diagnostics.CodeInputRequired, HclPath: "models", Filename: "irex.hcl"},
+	})
+	if len(got) != 1 || got[0].Code != diagnostics.CodeInputRequired {
+		t.Fatalf("want only the error left, got %+v", got)
+	}
+}
+
+func TestLintSeverityOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		diag     diagnostics.Diagnostic
+		want     []diagnostics.Severity
+	}{
+		{
+			name:     "warning turned off",
+			severity: "off",
+			diag:     diagnostics.Diagnostic{Severity: diagnostics.SeverityWarning, Code: diagnostics.CodeInputRecommended},
+			want:     nil,
+		},
+		{
+			name:     "warning downgraded",
+			severity: "hint",
+			diag:     diagnostics.Diagnostic{Severity: diagnostics.SeverityWarning, Code: diagnostics.CodeInputRecommended},
+			want:     []diagnostics.Severity{diagnostics.SeverityHint},
+		},
+		{
+			name:     "warning raised",
+			severity: "error",
+			diag:     diagnostics.Diagnostic{Severity: diagnostics.SeverityWarning, Code: diagnostics.CodeInputRecommended},
+			want:     []diagnostics.Severity{diagnostics.SeverityError},
+		},
+		{
+			name:     "error kept",
+			severity: "off",
+			diag:     diagnostics.Diagnostic{Severity: diagnostics.SeverityError, Code: diagnostics.CodeInputRequired},
+			want:     []diagnostics.Severity{diagnostics.SeverityError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := diagnostics.ParseSeverity(tt.severity)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lint := diagnostics.NewLint()
+			lint.SetSeverity(tt.diag.Code, s)
+			got := lint.Apply([]diagnostics.Diagnostic{tt.diag})
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want severities %v", got, tt.want)
+			}
+			for i, d := range got {
+				if d.Severity != tt.want[i] {
+					t.Errorf("severity %v, want %v", d.Severity, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Every warning the config checks raise must be one lint can silence.
+func TestConfigWarningsAreSuppressible(t *testing.T) {
+	cfg := &symbols.ConfigDefinition{Project: &symbols.ProjectBlock{
+		Name:    "demo",
+		Version: "1.0.0",
+		Paths:   &symbols.PathsBlock{},
+		Runtime: &symbols.RuntimeBlock{
+			Options: &symbols.RuntimeOptions{},
+			Service: &symbols.RuntimeServiceBlock{Options: &symbols.RuntimeServiceOptions{}},
+		},
+	}}
+	for _, d := range validate.ValidateConfig(cfg) {
+		if d.Severity != diagnostics.SeverityError && !diagnostics.Suppressible(d.Code) {
+			t.Errorf("%q is a %s under %s, which cannot be ignored", d.Message, d.Severity, d.Code)
+		}
+	}
+}
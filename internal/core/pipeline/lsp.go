@@ -2,86 +2,342 @@ package pipeline
 
 import (
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/kwizyHQ/irex/internal/core/normalize"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/resolve"
 	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
 	"github.com/kwizyHQ/irex/internal/core/validate"
 	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/zclconf/go-cty/cty"
 )
 
-func GetDiagnosticsForFile(filename string, content string) diagnostics.Diagnostics {
+// FileContext is what validating a single file takes from its project.
+type FileContext struct {
+	Lint    *diagnostics.Lint          // severity overrides, may be nil
+	Eval    *hcl.EvalContext           // knows the project's variables, may be nil
+	Profile string                     // active profile, may be empty
+	Locals  map[string]cty.Value       // schema locals, may be nil
+	Mixins  map[string][]symbols.Mixin // schema mixins by file, may be nil
+	FS      overlay.FS                 // reads the project's other files, nil means the OS
+}
+
+// loadSchemaContext fills in the schema locals and mixins of fc.
+func loadSchemaContext(fc *FileContext, configPath string) {
+	schemaFiles, _ := SpecFiles(configPath, fc.FS)
+	locals, _ := resolve.LoadLocals(fc.FS, schemaFiles, fc.Eval)
+	schemaOpts := ast.Options{Eval: functions.WithLocals(fc.Eval, locals), Profile: fc.Profile}
+	mixins := map[string][]symbols.Mixin{}
+	for _, fn := range schemaFiles {
+		var spec symbols.ModelsSpec
+		ast.ParseHCLFSWith(fc.FS, fn, &spec, schemaOpts)
+		if len(spec.Mixins) > 0 {
+			mixins[fn] = spec.Mixins
+		}
+	}
+	fc.Locals, fc.Mixins = locals, mixins
+}
+
+// GetDiagnosticsForFile validates one file in isolation. fc supplies the
+// project's severity overrides and variables; the file's own
+// `# irex:ignore` comments are always honoured.
+func GetDiagnosticsForFile(filename string, content string, fc FileContext) diagnostics.Diagnostics {
+	lint := fc.Lint
+	decodeOpts := ast.Options{Eval: fc.Eval, Profile: fc.Profile}
 	r := diagnostics.NewReporter()
 	fileType := GetFileType(filename)
 	switch fileType {
 	case "config":
 		configAST := &shared.ConfigAST{}
 		r.SetFilename(filename)
-		r.ExtendWithFilename(ast.ParseFromHCLContent(filename, content, configAST))
+		r.ExtendWithFilename(ast.ParseFromHCLContentWith(filename, content, configAST, decodeOpts))
 		r.ExtendWithFilename(validate.ValidateConfig(configAST))
+		r.ExtendWithFilename(CheckPaths(fc.FS, ResolvePaths(filename, configAST)))
 	case "schema":
 		schemaAST := &shared.SchemaAST{}
+		decodeOpts.Eval = functions.WithLocals(fc.Eval, fc.Locals)
 		r.SetFilename(filename)
-		r.ExtendWithFilename(ast.ParseFromHCLContent(filename, content, schemaAST))
+		r.ExtendWithFilename(ast.ParseFromHCLContentWith(filename, content, schemaAST, decodeOpts))
+		origin := addProjectMixins(schemaAST, filename, fc.Mixins)
+		r.ExtendWithFilename(relateToMixinFiles(normalize.ExpandMixins(schemaAST), origin))
 		r.ExtendWithFilename(validate.ValidateSchema(schemaAST))
 	case "service":
 		serviceAST := &shared.ServicesAST{}
 		r.SetFilename(filename)
-		r.ExtendWithFilename(ast.ParseFromHCLContent(filename, content, serviceAST))
+		r.ExtendWithFilename(ast.ParseFromHCLContentWith(filename, content, serviceAST, decodeOpts))
 		r.ExtendWithFilename(validate.ValidateService(serviceAST))
 	}
+	if lint == nil {
+		lint = diagnostics.NewLint()
+	}
+	ignores, ignoreDiags := IgnoresFromSource(filename, []byte(content))
+	lint.SetIgnores(filename, ignores)
+	r.Extend(ignoreDiags)
+
 	// let's merge the ranges as well (if not zeroRange)
 	diags := r.All()
-	table, err := WalkHCLSymbols(filename)
+	table, err := WalkHCLSource(filename, []byte(content))
 	if err != nil {
-		return r.All()
+		return lint.Apply(diags)
+	}
+	locateRanges(diags, func(fn string) *SymbolTable {
+		if fn == "" || fn == filename {
+			return &table
+		}
+		// related locations in other files, e.g. an included mixin
+		if other, err := WalkHCLSymbolsFS(fc.FS, fn); err == nil {
+			return &other
+		}
+		return nil
+	})
+	diags = lint.Apply(diags)
+	toEditorRanges(diags)
+	return diags
+}
+
+// addProjectMixins makes the mixins of the project's other schema files
+// available to spec, skipping names the file defines itself; clashes between
+// files are left to the workspace diagnostics. It returns the file each added
+// mixin comes from.
+func addProjectMixins(spec *shared.SchemaAST, filename string, mixins map[string][]symbols.Mixin) map[string]string {
+	files := make([]string, 0, len(mixins))
+	for fn := range mixins {
+		if !samePath(fn, filename) {
+			files = append(files, fn)
+		}
+	}
+	sort.Strings(files)
+	origin := map[string]string{}
+	for _, fn := range files {
+		for _, m := range mixins[fn] {
+			if !slices.ContainsFunc(spec.Mixins, func(o symbols.Mixin) bool { return o.Name == m.Name }) {
+				spec.Mixins = append(spec.Mixins, m)
+				origin[m.Name] = fn
+			}
+		}
+	}
+	return origin
+}
+
+// relateToMixinFiles points related locations inside mixins added by
+// addProjectMixins at the file that defines them.
+func relateToMixinFiles(diags []diagnostics.Diagnostic, origin map[string]string) []diagnostics.Diagnostic {
+	for i := range diags {
+		for j, rel := range diags[i].Related {
+			name, _, _ := strings.Cut(strings.TrimPrefix(rel.HclPath, "mixin."), ".")
+			if fn, ok := origin[name]; ok && strings.HasPrefix(rel.HclPath, "mixin.") {
+				diags[i].Related[j].Filename = fn
+			}
+		}
+	}
+	return diags
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// GetWorkspaceDiagnostics runs the full Build for the project rooted at
+// configPath and returns its diagnostics grouped by file, with ranges mapped
+// the same way as GetDiagnosticsForFile. Diagnostics without a file are
+// reported against the config file. It also returns the FileContext of the
+// project, taken from the same build, for validating open documents.
+func GetWorkspaceDiagnostics(configPath string, fsys overlay.FS) (map[string]diagnostics.Diagnostics, FileContext) {
+	var fc FileContext
+	_, diags := build(BuildOptions{ConfigPath: configPath, FS: fsys}, &fc)
+	if fc.Mixins == nil {
+		// the build stopped before the schema files
+		loadSchemaContext(&fc, configPath)
+	}
+
+	out := make(map[string]diagnostics.Diagnostics)
+	for _, d := range LocateDiagnostics(configPath, fsys, diags) {
+		out[d.Filename] = append(out[d.Filename], d)
 	}
+	for _, fileDiags := range out {
+		toEditorRanges(fileDiags)
+	}
+	return out, fc
+}
+
+// LocateDiagnostics gives every diagnostic a file (the config file when it
+// has none) and points those carrying an HclPath at their source, related
+// locations included. Ranges stay 1-based, as the CLI reports them.
+func LocateDiagnostics(configPath string, fsys overlay.FS, diags diagnostics.Diagnostics) diagnostics.Diagnostics {
+	fsys = overlay.Or(fsys)
+	tables := make(map[string]*SymbolTable)
+	tableFor := func(fn string) *SymbolTable {
+		if t, ok := tables[fn]; ok {
+			return t
+		}
+		var t *SymbolTable
+		if table, err := WalkHCLSymbolsFS(fsys, fn); err == nil {
+			t = &table
+		}
+		tables[fn] = t
+		return t
+	}
+	out := make(diagnostics.Diagnostics, len(diags))
+	for i, d := range diags {
+		if d.Filename == "" {
+			d.Filename = configPath
+		}
+		out[i] = d
+	}
+	locateRanges(out, tableFor)
+	return out
+}
+
+// locateRanges points diagnostics carrying an HclPath at the matching
+// attribute (or its parent block), and does the same for their related
+// locations. tableFor returns the symbols of a file, or nil when unknown.
+func locateRanges(diags diagnostics.Diagnostics, tableFor func(filename string) *SymbolTable) {
 	for i, d := range diags {
 		if d.HclPath != "" {
-			var rng hcl.Range
-			if table.Attrs[d.HclPath] != nil {
-				rng = table.Attrs[d.HclPath].ExprRange
-			} else {
-				// if we have no Attr, then we should issue error to parent block e.g. for project.name attribute we should point to project block
-				// split the hclPath by dot and remove last segment
-				parts := strings.Split(d.HclPath, ".")
-				if len(parts) > 1 {
-					parentPath := ""
-					for _, p := range parts[:len(parts)-1] {
-						if parentPath == "" {
-							parentPath = p
-						} else {
-							parentPath += "." + p
-						}
-					}
-					if table.Blocks[parentPath] != nil {
-						rng = table.Blocks[parentPath].BodyRange
-					}
+			if table := tableFor(d.Filename); table != nil {
+				if rng := pathRange(*table, d.HclPath, false); !rng.Empty() {
+					diags[i].Range = toRange(rng)
 				}
 			}
-			if table.Blocks[d.HclPath] != nil {
-				rng = table.Blocks[d.HclPath].BodyRange
+		}
+		if len(d.Related) == 0 {
+			continue
+		}
+		// copy, the slice may be shared with the reporter
+		related := make([]diagnostics.Related, len(d.Related))
+		for j, r := range d.Related {
+			if r.Filename == "" {
+				r.Filename = d.Filename
 			}
-			if rng.Empty() == false {
-				diags[i].Range = diagnostics.Range{
-					Start: diagnostics.Position(rng.Start),
-					End:   diagnostics.Position(rng.End),
+			if r.HclPath != "" && r.Range.Start.Line == 0 {
+				if table := tableFor(r.Filename); table != nil {
+					if rng := pathRange(*table, r.HclPath, r.First); !rng.Empty() {
+						r.Range = toRange(rng)
+					}
 				}
 			}
+			related[j] = r
 		}
-		// let's convert to vscode style range (end is exclusive)
-		if diags[i].Range.End.Line > 0 && diags[i].Range.End.Column > 0 {
-			diags[i].Range.End.Line -= 1
-			diags[i].Range.End.Column -= 1
+		diags[i].Related = related
+	}
+}
+
+// pathRange returns the range of the attribute or block at path. A missing
+// attribute falls back to its parent block, e.g. project.name points at the
+// project block. first selects the first of repeated blocks.
+func pathRange(table SymbolTable, path string, first bool) hcl.Range {
+	blocks := table.Blocks
+	if first {
+		blocks = table.FirstBlocks
+	}
+	if b := blocks[path]; b != nil {
+		return b.BodyRange
+	}
+	if a := table.Attrs[path]; a != nil {
+		return a.ExprRange
+	}
+	if i := strings.LastIndex(path, "."); i > 0 {
+		if b := table.Blocks[path[:i]]; b != nil {
+			return b.BodyRange
 		}
-		// convert start range too
-		if diags[i].Range.Start.Line > 0 && diags[i].Range.Start.Column > 0 {
-			diags[i].Range.Start.Line -= 1
-			diags[i].Range.Start.Column -= 1
+	}
+	return hcl.Range{}
+}
+
+func toRange(rng hcl.Range) diagnostics.Range {
+	return diagnostics.Range{
+		Start: diagnostics.Position(rng.Start),
+		End:   diagnostics.Position(rng.End),
+	}
+}
+
+// toEditorRanges converts 1-based HCL positions to 0-based editor positions.
+func toEditorRanges(diags diagnostics.Diagnostics) {
+	for i := range diags {
+		toEditorRange(&diags[i].Range)
+		for j := range diags[i].Related {
+			toEditorRange(&diags[i].Related[j].Range)
 		}
 	}
-	return diags
+}
+
+func toEditorRange(rng *diagnostics.Range) {
+	// let's convert to vscode style range (end is exclusive)
+	if rng.End.Line > 0 && rng.End.Column > 0 {
+		rng.End.Line -= 1
+		rng.End.Column -= 1
+	}
+	// convert start range too
+	if rng.Start.Line > 0 && rng.Start.Column > 0 {
+		rng.Start.Line -= 1
+		rng.Start.Column -= 1
+	}
+}
+
+// findConfigDepth bounds how many directory levels FindConfig searches below
+// its starting point, so opening a large folder such as $HOME stays cheap.
+const findConfigDepth = 3
+
+// FindConfig returns the irex.hcl governing path: the first one found walking
+// up from path, or else the shallowest one at most findConfigDepth levels
+// below it. It returns "" when none exists.
+func FindConfig(fsys overlay.FS, path string) string {
+	fsys = overlay.Or(fsys)
+	dir := path
+	if info, err := fsys.Stat(path); err == nil && !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	for current := dir; ; {
+		candidate := filepath.Join(current, "irex.hcl")
+		if _, err := fsys.Stat(candidate); err == nil {
+			return candidate
+		}
+		next := filepath.Dir(current)
+		if next == current {
+			break
+		}
+		current = next
+	}
+
+	// breadth-first search downwards through fsys, a few levels deep,
+	// skipping dependency and VCS folders
+	level := []string{dir}
+	for depth := 0; depth < findConfigDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, current := range level {
+			entries, err := fsys.Glob(filepath.Join(current, "*"))
+			if err != nil {
+				continue
+			}
+			for _, sub := range entries {
+				switch filepath.Base(sub) {
+				case "node_modules", ".git", "vendor", "dist":
+					continue
+				}
+				if info, err := fsys.Stat(sub); err != nil || !info.IsDir() {
+					continue
+				}
+				if _, err := fsys.Stat(filepath.Join(sub, "irex.hcl")); err == nil {
+					return filepath.Join(sub, "irex.hcl")
+				}
+				next = append(next, sub)
+			}
+		}
+		level = next
+	}
+	return ""
 }
 
 // GetFileType infers the type based on filename first, then the closest parent folder.
@@ -0,0 +1,328 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/normalize"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/core/validate"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+// moduleFile declares the modules a module directory imports in turn.
+const moduleFile = "module.hcl"
+
+// Module is a spec directory imported with a module block. Its symbols are
+// already prefixed with the module path, e.g. auth.User or auth.crypto.Key,
+// and references between them rewritten to match.
+type Module struct {
+	Name       string // module path, e.g. "auth" or "auth.crypto"
+	Dir        string
+	Files      []string // spec files read, module.hcl included
+	Models     []symbols.Model
+	Policies   symbols.PoliciesBlock
+	RateLimits symbols.RateLimitsBlock
+}
+
+// LoadModules loads the modules declared in cfg and those they import. Each
+// module is validated against its own files, so diagnostics point at the
+// module sources; problems with a module block itself, cycles included, are
+// reported in the file declaring it.
+func LoadModules(fsys overlay.FS, configPath string, cfg *shared.ConfigAST, opts ast.Options) ([]Module, diagnostics.Diagnostics) {
+	l := &moduleLoader{fsys: overlay.Or(fsys), opts: opts, reporter: diagnostics.NewReporter()}
+	if cfg != nil && len(cfg.Modules) > 0 {
+		// the project's own specs count as being imported
+		root := filepath.Clean(specificationsDir(configPath, cfg))
+		l.loadAll(cfg.Modules, configPath, "", []string{root}, []string{"project"})
+	}
+	return l.modules, l.reporter.All()
+}
+
+type moduleLoader struct {
+	fsys     overlay.FS
+	opts     ast.Options
+	reporter *diagnostics.Reporter
+	modules  []Module
+}
+
+// loadAll loads the module blocks declared in file. prefix is the module
+// path of the declaring module ("" for irex.hcl); stack holds the
+// directories being imported and names their module paths.
+func (l *moduleLoader) loadAll(blocks []symbols.ModuleBlock, file, prefix string, stack, names []string) {
+	for _, b := range blocks {
+		// unnamed, dotted and source-less modules are reported by ValidateModules
+		if b.Name == "" || b.Source == "" || strings.Contains(b.Name, ".") {
+			continue
+		}
+		l.load(b, file, prefix, stack, names)
+	}
+}
+
+func (l *moduleLoader) load(b symbols.ModuleBlock, file, prefix string, stack, names []string) {
+	name := prefix + b.Name
+	path := "module." + b.Name
+	dir := b.Source
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(file), dir)
+	}
+	dir = filepath.Clean(dir)
+
+	if i := slices.Index(stack, dir); i >= 0 {
+		chain := append(slices.Clone(names[i:]), name)
+		l.errorIn(file, "Module import cycle: "+strings.Join(chain, " → ")+".", diagnostics.CodeConfigModuleCycle, path+".source")
+		return
+	}
+	if info, err := l.fsys.Stat(dir); err != nil || !info.IsDir() {
+		l.errorIn(file, "Module '"+name+"' source "+b.Source+" is not a directory.", diagnostics.CodeConfigNotFound, path+".source")
+		return
+	}
+
+	mod := Module{Name: name, Dir: dir}
+	schemaFiles, _ := l.fsys.Glob(filepath.Join(dir, "schema", "*.hcl"))
+	serviceFiles, _ := l.fsys.Glob(filepath.Join(dir, "service", "*.hcl"))
+	mod.Files = append(slices.Clone(schemaFiles), serviceFiles...)
+
+	// ---------------- Schema ----------------
+	schema := symbols.ModelsSpec{ModelsBlock: &symbols.ModelsBlock{}}
+	schemaOpts, localDiags := schemaDecodeOptions(l.fsys, schemaFiles, l.opts)
+	l.reporter.Extend(localDiags)
+	schemaOK := len(localDiags) == 0
+	// fields set from a failed local would only repeat the error
+	if schemaOK {
+		for _, fn := range schemaFiles {
+			var spec symbols.ModelsSpec
+			if diags := ast.ParseHCLFSWith(l.fsys, fn, &spec, schemaOpts); len(diags) > 0 {
+				l.reporter.Extend(diags)
+				schemaOK = false
+				continue
+			}
+			schema.Mixins = append(schema.Mixins, spec.Mixins...)
+			if spec.ModelsBlock != nil {
+				schema.ModelsBlock.Models = append(schema.ModelsBlock.Models, spec.ModelsBlock.Models...)
+			}
+		}
+	}
+	if schemaOK && len(schemaFiles) > 0 {
+		l.reporter.Extend(locateInFiles(l.fsys, normalize.ExpandMixins(&schema), schemaFiles))
+		l.reporter.Extend(locateInFiles(l.fsys, validate.ValidateSchema(&schema), schemaFiles))
+	}
+	mod.Models = schema.ModelsBlock.Models
+
+	// ---------------- Services ----------------
+	for _, fn := range serviceFiles {
+		var spec symbols.ServiceDefinition
+		if diags := ast.ParseHCLFSWith(l.fsys, fn, &spec, l.opts); len(diags) > 0 {
+			l.reporter.Extend(diags)
+			continue
+		}
+		l.reporter.Extend(withFilename(validate.ValidateModuleService(&spec), fn))
+		if p := spec.Policies; p != nil {
+			mod.Policies.Presets = append(mod.Policies.Presets, p.Presets...)
+			mod.Policies.Customs = append(mod.Policies.Customs, p.Customs...)
+			mod.Policies.Groups = append(mod.Policies.Groups, p.Groups...)
+		}
+		if rl := spec.RateLimits; rl != nil {
+			// a module's rate limit defaults only apply to its own presets
+			for i := range rl.Presets {
+				if rl.Defaults != nil {
+					normalize.MergeFromDefaults(rl.Defaults, &rl.Presets[i])
+				}
+			}
+			mod.RateLimits.Presets = append(mod.RateLimits.Presets, rl.Presets...)
+			mod.RateLimits.Customs = append(mod.RateLimits.Customs, rl.Customs...)
+		}
+	}
+
+	// ---------------- Nested modules ----------------
+	var nested symbols.ModuleSpec
+	mf := filepath.Join(dir, moduleFile)
+	if _, err := l.fsys.Stat(mf); err == nil {
+		mod.Files = append(mod.Files, mf)
+		if diags := ast.ParseHCLFSWith(l.fsys, mf, &nested, ast.Options{Eval: l.opts.Eval}); len(diags) > 0 {
+			l.reporter.Extend(diags)
+		} else {
+			l.reporter.Extend(withFilename(validate.ValidateModules(nested.Modules), mf))
+		}
+	}
+	if len(schemaFiles)+len(serviceFiles)+len(nested.Modules) == 0 {
+		l.warnIn(file, "Module '"+name+"' at "+b.Source+" has no schema or service specs.", diagnostics.CodeInputRecommended, path+".source")
+	}
+
+	namespaceModule(&mod, nested.Modules)
+	l.modules = append(l.modules, mod)
+	l.loadAll(nested.Modules, mf, name+".", append(slices.Clone(stack), dir), append(slices.Clone(names), name))
+}
+
+func (l *moduleLoader) errorIn(file, msg, code, path string) {
+	r := diagnostics.NewReporter()
+	r.Error(msg, diagnostics.Range{}, code, path)
+	l.reporter.Extend(withFilename(r.All(), file))
+}
+
+func (l *moduleLoader) warnIn(file, msg, code, path string) {
+	r := diagnostics.NewReporter()
+	r.Warn(msg, diagnostics.Range{}, code, path)
+	l.reporter.Extend(withFilename(r.All(), file))
+}
+
+// namespaceModule prefixes the symbols of mod with its module path and
+// rewrites the references between them: a name the module defines, or one
+// starting with a module it imports (crypto.Key), gets the prefix; anything
+// else is left as written.
+func namespaceModule(mod *Module, imports []symbols.ModuleBlock) {
+	prefix := mod.Name + "."
+	imported := map[string]bool{}
+	for _, m := range imports {
+		imported[m.Name] = true
+	}
+	qualifier := func(local map[string]bool) func(string) string {
+		return func(ref string) string {
+			head, _, dotted := strings.Cut(ref, ".")
+			if local[ref] || (dotted && imported[head]) {
+				return prefix + ref
+			}
+			return ref
+		}
+	}
+
+	models := map[string]bool{}
+	for _, m := range mod.Models {
+		models[m.Name] = true
+	}
+	model := qualifier(models)
+	for i := range mod.Models {
+		m := &mod.Models[i]
+		m.Name = prefix + m.Name
+		if m.Relations == nil {
+			continue
+		}
+		for j := range m.Relations.HasMany {
+			m.Relations.HasMany[j].Ref = model(m.Relations.HasMany[j].Ref)
+		}
+		for j := range m.Relations.BelongsTo {
+			m.Relations.BelongsTo[j].Ref = model(m.Relations.BelongsTo[j].Ref)
+		}
+		for j := range m.Relations.ManyToMany {
+			m.Relations.ManyToMany[j].Ref = model(m.Relations.ManyToMany[j].Ref)
+		}
+	}
+
+	policies := map[string]bool{}
+	for _, p := range mod.Policies.Presets {
+		policies[p.Name] = true
+	}
+	for _, c := range mod.Policies.Customs {
+		policies[c.Name] = true
+	}
+	policy := qualifier(policies)
+	for i := range mod.Policies.Presets {
+		mod.Policies.Presets[i].Name = prefix + mod.Policies.Presets[i].Name
+	}
+	for i := range mod.Policies.Customs {
+		mod.Policies.Customs[i].Name = prefix + mod.Policies.Customs[i].Name
+	}
+	for i := range mod.Policies.Groups {
+		g := &mod.Policies.Groups[i]
+		g.Name = prefix + g.Name
+		members := make([]string, len(g.Policies))
+		for j, p := range g.Policies {
+			members[j] = policy(p)
+		}
+		g.Policies = members
+	}
+	for i := range mod.RateLimits.Presets {
+		mod.RateLimits.Presets[i].Name = prefix + mod.RateLimits.Presets[i].Name
+	}
+	for i := range mod.RateLimits.Customs {
+		mod.RateLimits.Customs[i].Name = prefix + mod.RateLimits.Customs[i].Name
+	}
+}
+
+// mergeModules adds the symbols of modules to the project's ASTs. A module
+// symbol that clashes with one already defined is reported against the
+// module block in irex.hcl and skipped.
+func mergeModules(ctx *shared.BuildContext, modules []Module) diagnostics.Diagnostics {
+	reporter := diagnostics.NewReporter()
+	if len(modules) == 0 {
+		return reporter.All()
+	}
+	if ctx.SchemaAST.ModelsBlock == nil {
+		ctx.SchemaAST.ModelsBlock = &symbols.ModelsBlock{}
+	}
+	if ctx.ServicesAST.Policies == nil {
+		ctx.ServicesAST.Policies = &symbols.PoliciesBlock{}
+	}
+	if ctx.ServicesAST.RateLimits == nil {
+		ctx.ServicesAST.RateLimits = &symbols.RateLimitsBlock{}
+	}
+
+	models := map[string]bool{}
+	for _, m := range ctx.SchemaAST.ModelsBlock.Models {
+		models[m.Name] = true
+	}
+	policies, rateLimits := map[string]bool{}, map[string]bool{}
+	for _, p := range ctx.ServicesAST.Policies.Presets {
+		policies[p.Name] = true
+	}
+	for _, c := range ctx.ServicesAST.Policies.Customs {
+		policies[c.Name] = true
+	}
+	for _, g := range ctx.ServicesAST.Policies.Groups {
+		policies[g.Name] = true
+	}
+	for _, p := range ctx.ServicesAST.RateLimits.Presets {
+		rateLimits[p.Name] = true
+	}
+	for _, c := range ctx.ServicesAST.RateLimits.Customs {
+		rateLimits[c.Name] = true
+	}
+
+	for _, mod := range modules {
+		top, _, _ := strings.Cut(mod.Name, ".")
+		clash := func(kind string, names map[string]bool, name string) bool {
+			if names[name] {
+				reporter.Error("Module '"+mod.Name+"' redefines "+kind+" '"+name+"'.", diagnostics.Range{},
+					diagnostics.CodeInputDuplicate, "module."+top)
+				return true
+			}
+			names[name] = true
+			return false
+		}
+		for _, m := range mod.Models {
+			if !clash("model", models, m.Name) {
+				ctx.SchemaAST.ModelsBlock.Models = append(ctx.SchemaAST.ModelsBlock.Models, m)
+			}
+		}
+		for _, p := range mod.Policies.Presets {
+			if !clash("policy", policies, p.Name) {
+				ctx.ServicesAST.Policies.Presets = append(ctx.ServicesAST.Policies.Presets, p)
+			}
+		}
+		for _, c := range mod.Policies.Customs {
+			if !clash("policy", policies, c.Name) {
+				ctx.ServicesAST.Policies.Customs = append(ctx.ServicesAST.Policies.Customs, c)
+			}
+		}
+		for _, g := range mod.Policies.Groups {
+			if !clash("policy group", policies, g.Name) {
+				ctx.ServicesAST.Policies.Groups = append(ctx.ServicesAST.Policies.Groups, g)
+			}
+		}
+		for _, p := range mod.RateLimits.Presets {
+			if !clash("rate limit", rateLimits, p.Name) {
+				ctx.ServicesAST.RateLimits.Presets = append(ctx.ServicesAST.RateLimits.Presets, p)
+			}
+		}
+		for _, c := range mod.RateLimits.Customs {
+			if !clash("rate limit", rateLimits, c.Name) {
+				ctx.ServicesAST.RateLimits.Customs = append(ctx.ServicesAST.RateLimits.Customs, c)
+			}
+		}
+	}
+	return reporter.All()
+}
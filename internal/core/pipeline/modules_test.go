@@ -0,0 +1,173 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+const modelSpec = `models {
+  model "%s" {
+    field "name" {
+      type = "string"
+    }
+  }
+}
+`
+
+func TestLoadModules(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		check func(t *testing.T, root string, mods []Module, diags diagnostics.Diagnostics)
+	}{
+		{
+			name: "two-module cycle",
+			files: map[string]string{
+				"irex.hcl":                 `module "a" { source = "./mods/a" }`,
+				"mods/a/module.hcl":        `module "b" { source = "../b" }`,
+				"mods/a/schema/models.hcl": strings.Replace(modelSpec, "%s", "A", 1),
+				"mods/b/module.hcl":        `module "a" { source = "../a" }`,
+				"mods/b/schema/models.hcl": strings.Replace(modelSpec, "%s", "B", 1),
+				"spec/schema/models.hcl":   strings.Replace(modelSpec, "%s", "Root", 1),
+			},
+			check: func(t *testing.T, root string, mods []Module, diags diagnostics.Diagnostics) {
+				d := only(t, diags, diagnostics.CodeConfigModuleCycle)
+				if d.Message != "Module import cycle: a → a.b → a.b.a." {
+					t.Errorf("message %q", d.Message)
+				}
+				if d.Filename != filepath.Join(root, "mods/b/module.hcl") || d.HclPath != "module.a.source" {
+					t.Errorf("reported in %s at %s, want mods/b/module.hcl at module.a.source", d.Filename, d.HclPath)
+				}
+				if names := moduleNames(mods); names != "a a.b" {
+					t.Errorf("loaded modules %q, want \"a a.b\"", names)
+				}
+			},
+		},
+		{
+			name: "self-import of the spec dir",
+			files: map[string]string{
+				"irex.hcl":               `module "self" { source = "./spec" }`,
+				"spec/schema/models.hcl": strings.Replace(modelSpec, "%s", "Root", 1),
+			},
+			check: func(t *testing.T, root string, mods []Module, diags diagnostics.Diagnostics) {
+				d := only(t, diags, diagnostics.CodeConfigModuleCycle)
+				if d.Message != "Module import cycle: project → self." || d.Filename != filepath.Join(root, "irex.hcl") {
+					t.Errorf("got %q in %s", d.Message, d.Filename)
+				}
+				if len(mods) != 0 {
+					t.Errorf("loaded %q", moduleNames(mods))
+				}
+			},
+		},
+		{
+			name: "namespaced references",
+			files: map[string]string{
+				"irex.hcl":               `module "auth" { source = "./auth" }`,
+				"spec/schema/models.hcl": strings.Replace(modelSpec, "%s", "Order", 1),
+				"auth/module.hcl":        `module "crypto" { source = "./crypto" }`,
+				"auth/schema/models.hcl": `models {
+  model "User" {
+    field "name" {
+      type = "string"
+    }
+  }
+  model "Session" {
+    field "token" {
+      type = "string"
+    }
+    relations {
+      belongsTo "user" {
+        ref = "User"
+      }
+      belongsTo "key" {
+        ref = "crypto.Key"
+      }
+      belongsTo "order" {
+        ref = "Order"
+      }
+    }
+  }
+}
+`,
+				"auth/crypto/schema/models.hcl": strings.Replace(modelSpec, "%s", "Key", 1),
+			},
+			check: func(t *testing.T, root string, mods []Module, diags diagnostics.Diagnostics) {
+				if len(diags) != 0 {
+					t.Fatalf("unexpected diagnostics %+v", diags)
+				}
+				if names := moduleNames(mods); names != "auth auth.crypto" {
+					t.Fatalf("loaded modules %q", names)
+				}
+				var models []string
+				refs := map[string]string{}
+				for _, mod := range mods {
+					for _, m := range mod.Models {
+						models = append(models, m.Name)
+						if m.Relations == nil {
+							continue
+						}
+						for _, r := range m.Relations.BelongsTo {
+							refs[r.Name] = r.Ref
+						}
+					}
+				}
+				if got := strings.Join(models, " "); got != "auth.User auth.Session auth.crypto.Key" {
+					t.Errorf("models %q", got)
+				}
+				want := map[string]string{"user": "auth.User", "key": "auth.crypto.Key", "order": "Order"}
+				for name, ref := range want {
+					if refs[name] != ref {
+						t.Errorf("relation %s refers to %q, want %q", name, refs[name], ref)
+					}
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				if name == "irex.hcl" {
+					content = "project {\n  paths {\n    specifications = \"./spec\"\n  }\n}\n\n" + content + "\n"
+				}
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			configPath := filepath.Join(root, "irex.hcl")
+			cfg := &shared.ConfigAST{}
+			if diags := ast.ParseHCLFSWith(nil, configPath, cfg, ast.Options{}); len(diags) > 0 {
+				t.Fatalf("irex.hcl: %+v", diags)
+			}
+			mods, diags := LoadModules(nil, configPath, cfg, ast.Options{})
+			tt.check(t, root, mods, diags)
+		})
+	}
+}
+
+// only returns the one diagnostic in diags, failing unless it has code.
+func only(t *testing.T, diags diagnostics.Diagnostics, code string) diagnostics.Diagnostic {
+	t.Helper()
+	if len(diags) != 1 || diags[0].Code != code {
+		t.Fatalf("want one %s diagnostic, got %+v", code, diags)
+	}
+	return diags[0]
+}
+
+func moduleNames(mods []Module) string {
+	names := make([]string, len(mods))
+	for i, m := range mods {
+		names[i] = m.Name
+	}
+	return strings.Join(names, " ")
+}
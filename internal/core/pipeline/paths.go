@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/kwizyHQ/irex/internal/ir"
+)
+
+// ResolvePaths resolves the paths block against the directory of
+// configPath, never the process cwd, so every command reads and writes the
+// same directories wherever it is run from. Unset paths stay empty.
+func ResolvePaths(configPath string, cfg *shared.ConfigAST) ir.IRPaths {
+	root := filepath.Dir(configPath)
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	out := ir.IRPaths{Root: root}
+	if cfg == nil || cfg.Project == nil || cfg.Project.Paths == nil {
+		return out
+	}
+	p := cfg.Project.Paths
+	out.Specifications = resolveIn(root, p.Specifications)
+	out.Templates = resolveIn(root, p.Templates)
+	out.Output = resolveIn(root, p.Output)
+	return out
+}
+
+func resolveIn(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return filepath.Clean(path)
+}
+
+// CheckPaths reports a missing specifications directory and an output
+// directory outside the project root. The output directory itself may be
+// missing, generation creates it, and so may the templates directory: the
+// built-in templates are used for anything it does not override.
+func CheckPaths(fsys overlay.FS, paths ir.IRPaths) []diagnostics.Diagnostic {
+	fsys = overlay.Or(fsys)
+	r := diagnostics.NewReporter()
+	isDir := func(path string) bool {
+		info, err := fsys.Stat(path)
+		return err == nil && info.IsDir()
+	}
+	if paths.Specifications != "" && !isDir(paths.Specifications) {
+		r.Error("Specifications directory "+paths.Specifications+" does not exist.", diagnostics.Range{}, diagnostics.CodeConfigNotFound, "project.paths.specifications")
+	}
+	if paths.Output != "" {
+		if rel, err := filepath.Rel(paths.Root, paths.Output); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			r.Error("Output directory "+paths.Output+" is outside the project root "+paths.Root+".", diagnostics.Range{}, diagnostics.CodeInputInvalid, "project.paths.output")
+		} else if info, err := fsys.Stat(paths.Output); err == nil && !info.IsDir() {
+			r.Error("Output path "+paths.Output+" is a file, not a directory.", diagnostics.Range{}, diagnostics.CodeInputInvalid, "project.paths.output")
+		}
+	}
+	return r.All()
+}
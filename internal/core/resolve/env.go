@@ -0,0 +1,88 @@
+// Package resolve checks the references a spec makes to its surroundings,
+// such as env() variables, against the environment irex runs in.
+package resolve
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/dotenv-org/godotenvvault"
+	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Env is the environment env() references resolve against: the process
+// environment on top of the project's .env file, or its decrypted
+// .env.vault when DOTENV_KEY is set. Process variables win, as with
+// godotenvvault.Load.
+type Env struct {
+	files map[string]string
+}
+
+// LoadEnv reads the env files next to the config at configPath. The error
+// reports a file that exists but could not be read or decrypted.
+func LoadEnv(fsys overlay.FS, configPath string) (*Env, error) {
+	fsys = overlay.Or(fsys)
+	env := &Env{files: map[string]string{}}
+	dir := filepath.Dir(configPath)
+
+	name, parse := ".env", godotenvvault.UnmarshalBytes
+	if _, ok := os.LookupEnv("DOTENV_KEY"); ok {
+		name = ".env.vault"
+		parse = func(src []byte) (map[string]string, error) {
+			return godotenvvault.Parse(bytes.NewReader(src))
+		}
+	}
+	path := filepath.Join(dir, name)
+	if _, err := fsys.Stat(path); err != nil {
+		return env, nil
+	}
+	src, err := fsys.ReadFile(path)
+	if err != nil {
+		return env, err
+	}
+	values, err := parse(src)
+	if err != nil {
+		return env, err
+	}
+	env.files = values
+	return env, nil
+}
+
+// Lookup returns the value of name and whether it is set.
+func (e *Env) Lookup(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	v, ok := e.files[name]
+	return v, ok
+}
+
+// CheckConfig reports env() references in cfg that have no default and are
+// not set in env. secret() references are left alone: they are resolved
+// where the generated code runs, never at build time.
+func CheckConfig(cfg *shared.ConfigAST, env *Env) []diagnostics.Diagnostic {
+	reporter := diagnostics.NewReporter()
+	if cfg == nil || cfg.Project == nil || cfg.Project.Runtime == nil {
+		return reporter.All()
+	}
+	check := func(v cty.Value, path string) {
+		val, err := functions.DecodeValue(v)
+		if err != nil || val.Ref == nil || val.Ref.Kind != functions.EnvKindEnv || val.Ref.Default != nil {
+			return
+		}
+		if _, ok := env.Lookup(val.Ref.Name); !ok {
+			reporter.Warn("Environment variable '"+val.Ref.Name+"' is not set and env() has no default.",
+				diagnostics.Range{}, diagnostics.CodeConfigEnvMissing, path)
+		}
+	}
+	if s := cfg.Project.Runtime.Schema; s != nil && s.Options != nil {
+		check(s.Options.URI, "project.runtime.schema.options.uri")
+		check(s.Options.DB, "project.runtime.schema.options.db")
+	}
+	return reporter.All()
+}
@@ -0,0 +1,160 @@
+package resolve
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// local is one `name = expression` of a locals block.
+type local struct {
+	attr *hcl.Attribute
+	file string
+	deps []string // locals the expression reads
+}
+
+// LoadLocals evaluates the locals blocks of files, which share one
+// namespace. A local may read other locals, var() and the other functions
+// of evalCtx, in any order; duplicates, unknown locals and reference cycles
+// are reported, and locals that fail evaluate to an unknown value. A local
+// that only reads a failed one is not reported again. Syntax errors are left
+// for the main decode to report.
+func LoadLocals(fsys overlay.FS, files []string, evalCtx *hcl.EvalContext) (map[string]cty.Value, diagnostics.Diagnostics) {
+	fsys = overlay.Or(fsys)
+	reporter := diagnostics.NewReporter()
+	values := map[string]cty.Value{}
+
+	declared := map[string]*local{}
+	var names []string
+	for _, fn := range files {
+		src, err := fsys.ReadFile(fn)
+		if err != nil {
+			continue
+		}
+		file, _ := hclsyntax.ParseConfig(src, fn, hcl.InitialPos)
+		if file == nil {
+			continue
+		}
+		content, _, _ := file.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "locals"}},
+		})
+		r := diagnostics.NewReporter()
+		r.SetFilename(fn)
+		for _, block := range content.Blocks {
+			attrs, _ := block.Body.JustAttributes()
+			for _, attr := range sortedAttributes(attrs) {
+				if first, exists := declared[attr.Name]; exists {
+					r.Error("Duplicate local name: "+attr.Name, toRange(attr.NameRange), diagnostics.CodeInputDuplicate, "")
+					r.Relate(diagnostics.Related{Message: "First defined here", Range: toRange(first.attr.NameRange), Filename: first.file})
+					continue
+				}
+				l := &local{attr: attr, file: fn}
+				for _, t := range attr.Expr.Variables() {
+					if t.RootName() != "local" || len(t) < 2 {
+						continue
+					}
+					if step, ok := t[1].(hcl.TraverseAttr); ok {
+						l.deps = append(l.deps, step.Name)
+					}
+				}
+				declared[attr.Name] = l
+				names = append(names, attr.Name)
+			}
+		}
+		reporter.Extend(r.All())
+	}
+
+	// evaluate in dependency order; whatever is left reads an unknown
+	// local or sits on a cycle
+	pending := names
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		var next []string
+		for _, name := range pending {
+			l := declared[name]
+			if !depsResolved(l, values) {
+				next = append(next, name)
+				continue
+			}
+			val, diags := l.attr.Expr.Value(functions.WithLocals(evalCtx, values))
+			if diags.HasErrors() {
+				r := diagnostics.NewReporter()
+				r.FromHCL(diags)
+				reporter.Extend(r.All())
+				val = cty.DynamicVal
+			}
+			values[name] = val
+			progress = true
+		}
+		pending = next
+	}
+	for _, name := range pending {
+		l := declared[name]
+		r := diagnostics.NewReporter()
+		r.SetFilename(l.file)
+		missing := ""
+		for _, dep := range l.deps {
+			if _, ok := declared[dep]; !ok {
+				missing = dep
+				break
+			}
+		}
+		switch {
+		case missing != "":
+			r.Error("Local '"+name+"' reads local."+missing+", which is not declared.", toRange(l.attr.Expr.Range()), diagnostics.CodeInputInvalid, "")
+		case onCycle(name, declared):
+			r.Error("Local '"+name+"' is part of a reference cycle.", toRange(l.attr.NameRange), diagnostics.CodeInputInvalid, "")
+		}
+		reporter.Extend(r.All())
+		values[name] = cty.DynamicVal
+	}
+	return values, reporter.All()
+}
+
+func depsResolved(l *local, values map[string]cty.Value) bool {
+	for _, dep := range l.deps {
+		if _, ok := values[dep]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// onCycle reports whether name can reach itself through its dependencies.
+func onCycle(name string, declared map[string]*local) bool {
+	visited := map[string]bool{}
+	var reaches func(from string) bool
+	reaches = func(from string) bool {
+		l, ok := declared[from]
+		if !ok || visited[from] {
+			return false
+		}
+		visited[from] = true
+		for _, dep := range l.deps {
+			if dep == name || reaches(dep) {
+				return true
+			}
+		}
+		return false
+	}
+	return reaches(name)
+}
+
+// sortedAttributes returns attrs in source order.
+func sortedAttributes(attrs hcl.Attributes) []*hcl.Attribute {
+	out := make([]*hcl.Attribute, 0, len(attrs))
+	for _, a := range attrs {
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Range.Start.Byte < out[j].Range.Start.Byte })
+	return out
+}
+
+func toRange(rng hcl.Range) diagnostics.Range {
+	return diagnostics.Range{Start: diagnostics.Position(rng.Start), End: diagnostics.Position(rng.End)}
+}
@@ -0,0 +1,62 @@
+package resolve
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+)
+
+func TestLoadLocals(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{`locals {
+  slug  = "${local.base}-${local.id}"
+  id    = 42
+  loopA = local.loopB
+}
+`,
+		`locals {
+  base  = "post"
+  loopB = local.loopA
+  bad   = local.missing
+  id    = 7
+}
+`,
+	}
+	var paths []string
+	for i, src := range files {
+		path := filepath.Join(dir, string(rune('a'+i))+".hcl")
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	values, diags := LoadLocals(nil, paths, nil)
+
+	// locals read each other across files and in any order
+	if v := values["slug"]; !v.IsKnown() || v.AsString() != "post-42" {
+		t.Errorf("slug = %#v, want \"post-42\"", v)
+	}
+	for _, name := range []string{"loopA", "loopB", "bad"} {
+		if v, ok := values[name]; !ok || v.IsKnown() {
+			t.Errorf("%s = %#v, want an unknown value", name, v)
+		}
+	}
+
+	want := map[string]string{
+		"Duplicate local name: id":                                diagnostics.CodeInputDuplicate,
+		"Local 'loopA' is part of a reference cycle.":             diagnostics.CodeInputInvalid,
+		"Local 'loopB' is part of a reference cycle.":             diagnostics.CodeInputInvalid,
+		"Local 'bad' reads local.missing, which is not declared.": diagnostics.CodeInputInvalid,
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %+v", len(diags), len(want), diags)
+	}
+	for _, d := range diags {
+		if code, ok := want[d.Message]; !ok || d.Code != code {
+			t.Errorf("unexpected diagnostic %s: %q", d.Code, d.Message)
+		}
+	}
+}
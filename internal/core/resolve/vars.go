@@ -0,0 +1,196 @@
+package resolve
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// VarOptions are the variable values given on the command line.
+type VarOptions struct {
+	// Files are HCL files of `name = value` lines (--var-file), applied in order.
+	Files []string
+	// Values are --var name=value pairs; they win over Files.
+	Values map[string]string
+}
+
+// ParseVarFlags splits --var name=value flags into VarOptions.Values.
+func ParseVarFlags(flags []string) (map[string]string, error) {
+	values := make(map[string]string, len(flags))
+	for _, f := range flags {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid --var %q, expected name=value", f)
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values, nil
+}
+
+// LoadVariables evaluates the `variables` block of the config at
+// configPath: each variable starts at its default and is then overridden by
+// the var files and finally by the --var values. Variables left without a
+// value are reported and evaluate to null.
+//
+// The block is read in a pre-pass so that var() can be used anywhere,
+// irex.hcl included. Syntax errors are left for the main decode to report.
+func LoadVariables(fsys overlay.FS, configPath string, opts VarOptions) (map[string]cty.Value, diagnostics.Diagnostics) {
+	fsys = overlay.Or(fsys)
+	reporter := diagnostics.NewReporter()
+	zeroRange := diagnostics.Range{}
+	vars := map[string]cty.Value{}
+
+	src, err := fsys.ReadFile(configPath)
+	if err != nil {
+		return vars, reporter.All()
+	}
+	file, _ := hclsyntax.ParseConfig(src, configPath, hcl.InitialPos)
+	if file == nil {
+		return vars, reporter.All()
+	}
+	content, _, _ := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "variables"}},
+	})
+
+	types := map[string]cty.Type{}
+	for _, block := range content.Blocks {
+		var def symbols.VariablesBlock
+		// decode errors are reported again, with the full context, by the main decode
+		_ = gohcl.DecodeBody(block.Body, functions.EvalContext(nil), &def)
+		for _, v := range def.Variables {
+			path := "variables.variable." + v.Name
+			if _, exists := types[v.Name]; exists {
+				reporter.Error("Duplicate variable name: "+v.Name, zeroRange, diagnostics.CodeInputDuplicate, path)
+				reporter.Relate(diagnostics.Related{Message: "First defined here", HclPath: path, First: true})
+				continue
+			}
+			ty, err := variableType(v)
+			if err != nil {
+				reporter.Error("Variable '"+v.Name+"': "+err.Error()+".", zeroRange, diagnostics.CodeInputInvalid, path+".type")
+				ty = cty.DynamicPseudoType
+			}
+			types[v.Name] = ty
+			vars[v.Name] = cty.NullVal(ty)
+			if v.Default == cty.NilVal || v.Default.IsNull() {
+				continue
+			}
+			val, err := convert.Convert(v.Default, ty)
+			if err != nil {
+				reporter.Error("Default of variable '"+v.Name+"': "+err.Error()+".", zeroRange, diagnostics.CodeInputInvalid, path+".default")
+				continue
+			}
+			vars[v.Name] = val
+		}
+	}
+
+	for _, fn := range opts.Files {
+		loadVarFile(fsys, fn, types, vars, reporter)
+	}
+
+	names := make([]string, 0, len(opts.Values))
+	for name := range opts.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ty, ok := types[name]
+		if !ok {
+			reporter.Error("--var "+name+": no variable '"+name+"' is declared in the variables block.", zeroRange, diagnostics.CodeInputInvalid, "variables")
+			continue
+		}
+		val, err := convert.Convert(cty.StringVal(opts.Values[name]), ty)
+		if err != nil {
+			reporter.Error("--var "+name+": "+err.Error()+".", zeroRange, diagnostics.CodeInputInvalid, "variables.variable."+name)
+			continue
+		}
+		vars[name] = val
+	}
+
+	declared := make([]string, 0, len(vars))
+	for name := range vars {
+		declared = append(declared, name)
+	}
+	sort.Strings(declared)
+	for _, name := range declared {
+		if vars[name].IsNull() {
+			reporter.Error("Variable '"+name+"' has no value; give it a default or pass --var "+name+"=...", zeroRange,
+				diagnostics.CodeInputRequired, "variables.variable."+name)
+		}
+	}
+	return vars, reporter.All()
+}
+
+// variableType reads the declared type of v, inferring it from the default
+// when omitted.
+func variableType(v symbols.Variable) (cty.Type, error) {
+	switch v.Type {
+	case "string":
+		return cty.String, nil
+	case "number":
+		return cty.Number, nil
+	case "bool":
+		return cty.Bool, nil
+	case "":
+		if v.Default != cty.NilVal && !v.Default.IsNull() {
+			return v.Default.Type(), nil
+		}
+		return cty.String, nil
+	default:
+		return cty.NilType, fmt.Errorf("unknown type %q, expected string, number or bool", v.Type)
+	}
+}
+
+// loadVarFile applies the `name = value` attributes of the var file fn.
+func loadVarFile(fsys overlay.FS, fn string, types map[string]cty.Type, vars map[string]cty.Value, reporter *diagnostics.Reporter) {
+	src, err := fsys.ReadFile(fn)
+	if err != nil {
+		reporter.Error("We couldn't read the var file at "+fn+": "+err.Error(), diagnostics.Range{}, diagnostics.CodeConfigReadError, "variables")
+		return
+	}
+	file, diags := hclsyntax.ParseConfig(src, fn, hcl.InitialPos)
+	r := diagnostics.NewReporter()
+	r.SetFilename(fn)
+	r.FromHCL(diags)
+	if file == nil {
+		reporter.Extend(r.All())
+		return
+	}
+	attrs, diags := file.Body.JustAttributes()
+	r.FromHCL(diags)
+
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attr := attrs[name]
+		rng := diagnostics.Range{Start: diagnostics.Position(attr.NameRange.Start), End: diagnostics.Position(attr.NameRange.End)}
+		ty, ok := types[name]
+		if !ok {
+			r.Warn("Variable '"+name+"' is not declared in the variables block.", rng, diagnostics.CodeInputIgnored, "")
+			continue
+		}
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			r.FromHCL(diags)
+			continue
+		}
+		if val, err = convert.Convert(val, ty); err != nil {
+			r.Error("Variable '"+name+"': "+err.Error()+".", rng, diagnostics.CodeInputInvalid, "")
+			continue
+		}
+		vars[name] = val
+	}
+	reporter.Extend(r.All())
+}
@@ -1,46 +1,142 @@
 package semantic
 
 import (
+	"sort"
+
 	"github.com/kwizyHQ/irex/internal/core/symbols"
 	"github.com/kwizyHQ/irex/internal/diagnostics"
 )
 
-// ValidateServiceAST checks that all model names referenced in serviceAst are defined in schemaAst.
-// Returns a slice of diagnostics for any missing models.
+// ValidateServiceAST checks that all model names referenced in serviceAst are defined in schemaAst,
+// and that every apply block references a defined policy or rate limit.
+// Returns a slice of diagnostics for any missing references.
 func CheckServiceSemantic(serviceAst *symbols.ServiceDefinition, schemaAst *symbols.ModelsSpec) []diagnostics.Diagnostic {
 	reporter := diagnostics.NewReporter()
 	zeroRange := diagnostics.Range{}
-	source := "validate.service.modelref"
 
-	// Build a set of all model names in schemaAst
-	modelNames := map[string]struct{}{}
+	// Map every model name in schemaAst to the path of its definition
+	modelNames := map[string]string{}
 	if schemaAst != nil && schemaAst.ModelsBlock != nil {
 		for _, m := range schemaAst.ModelsBlock.Models {
-			modelNames[m.Name] = struct{}{}
+			modelNames[m.Name] = "models.model." + m.Name
 		}
 	}
+	policyNames, rateLimitNames := applyTargets(serviceAst)
 
-	// Helper to check a Service and its nested services recursively
-	var checkService func(s symbols.Service)
-	checkService = func(s symbols.Service) {
+	// checkApply validates apply blocks declared under the block at prefix.
+	checkApply := func(applies []symbols.ApplyBlock, prefix string) {
+		for _, a := range applies {
+			applyPath := prefix + ".apply." + a.Type + "." + a.Name
+			switch a.Type {
+			case "policy":
+				if _, ok := policyNames[a.Name]; !ok {
+					hint, related := didYouMean(a.Name, policyNames)
+					reporter.Error("Applied policy '"+a.Name+"' is not defined"+hint, zeroRange,
+						diagnostics.CodeServicePolicyNotFound, applyPath)
+					reporter.Relate(related...)
+				}
+			case "rate_limit":
+				if _, ok := rateLimitNames[a.Name]; !ok {
+					hint, related := didYouMean(a.Name, rateLimitNames)
+					reporter.Error("Applied rate limit '"+a.Name+"' is not defined"+hint, zeroRange,
+						diagnostics.CodeServiceRateLimitNotFound, applyPath)
+					reporter.Relate(related...)
+				}
+			}
+			for _, rl := range a.RateLimits {
+				if _, ok := rateLimitNames[rl]; !ok {
+					hint, related := didYouMean(rl, rateLimitNames)
+					reporter.Error("Rate limit '"+rl+"' is not defined"+hint, zeroRange,
+						diagnostics.CodeServiceRateLimitNotFound, applyPath+".rate_limits")
+					reporter.Relate(related...)
+				}
+			}
+		}
+	}
+	checkOperations := func(ops []symbols.Operation, prefix string) {
+		for _, op := range ops {
+			checkApply(op.Apply, prefix+".operation."+op.Name)
+		}
+	}
+
+	// Helper to check a Service and its nested services recursively.
+	// prefix is the HCL block path of the parent so the diagnostic points at
+	// the exact `model` attribute (e.g. services.service.users.model).
+	var checkService func(s symbols.Service, prefix string)
+	checkService = func(s symbols.Service, prefix string) {
+		blockPath := prefix + ".service." + s.Name
 		if s.Model != "" {
 			if _, ok := modelNames[s.Model]; !ok {
-				reporter.Error("Service '"+s.Name+"' references undefined model '"+s.Model+"'", zeroRange,
-					"service.model.not_found", source)
+				hint, related := didYouMean(s.Model, modelNames)
+				reporter.Error("Service '"+s.Name+"' references undefined model '"+s.Model+"'"+hint, zeroRange,
+					diagnostics.CodeServiceModelNotFound, blockPath+".model")
+				reporter.Relate(related...)
 			}
 		}
+		checkApply(s.Apply, blockPath)
+		checkOperations(s.Operations, blockPath)
 		// Recurse into nested services
 		for _, nested := range s.Services {
-			checkService(nested)
+			checkService(nested, blockPath)
 		}
 	}
 
 	// Check all top-level services
 	if serviceAst != nil && serviceAst.Services != nil {
+		checkOperations(serviceAst.Services.Operations, "services")
 		for _, svc := range serviceAst.Services.Services {
-			checkService(svc)
+			checkService(svc, "services")
 		}
 	}
 
 	return reporter.All()
 }
+
+// applyTargets returns the names an apply block may reference, mapped to the
+// path of their definition: policy presets, custom policies and groups, and
+// rate limit presets and customs.
+func applyTargets(serviceAst *symbols.ServiceDefinition) (policies, rateLimits map[string]string) {
+	policies = map[string]string{}
+	rateLimits = map[string]string{}
+	if serviceAst == nil {
+		return
+	}
+	if serviceAst.Policies != nil {
+		for _, p := range serviceAst.Policies.Presets {
+			policies[p.Name] = "policies.policy." + p.Name
+		}
+		for _, c := range serviceAst.Policies.Customs {
+			policies[c.Name] = "policies.custom." + c.Name
+		}
+		for _, g := range serviceAst.Policies.Groups {
+			policies[g.Name] = "policies.group." + g.Name
+		}
+	}
+	if serviceAst.RateLimits != nil {
+		for _, p := range serviceAst.RateLimits.Presets {
+			rateLimits[p.Name] = "rate_limits.preset." + p.Name
+		}
+		for _, c := range serviceAst.RateLimits.Customs {
+			rateLimits[c.Name] = "rate_limits.custom." + c.Name
+		}
+	}
+	return
+}
+
+// didYouMean formats a suggestion suffix for an unknown name, or "" when
+// nothing is close, along with a related location at the suggestion's
+// definition. known maps names to the path of their definition.
+func didYouMean(name string, known map[string]string) (string, []diagnostics.Related) {
+	candidates := make([]string, 0, len(known))
+	for k := range known {
+		candidates = append(candidates, k)
+	}
+	sort.Strings(candidates)
+	if best := ClosestName(name, candidates); best != "" {
+		return ". Did you mean '" + best + "'?", []diagnostics.Related{{
+			Message: "'" + best + "' is defined here",
+			HclPath: known[best],
+		}}
+	}
+	return "", nil
+}
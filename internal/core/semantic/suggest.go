@@ -0,0 +1,43 @@
+package semantic
+
+import "strings"
+
+// ClosestName returns the candidate nearest to name by edit distance
+// (case-insensitive), or "" when no candidate is reasonably close.
+func ClosestName(name string, candidates []string) string {
+	best := ""
+	bestDist := -1
+	lname := strings.ToLower(name)
+	for _, c := range candidates {
+		d := levenshtein(lname, strings.ToLower(c))
+		if bestDist == -1 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	// anything needing more edits than half the name is a different word
+	if bestDist == -1 || bestDist > (len(name)+1)/2 {
+		return ""
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
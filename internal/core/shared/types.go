@@ -16,4 +16,8 @@ type BuildContext struct {
 	ServicesAST *ServicesAST
 	// add more ASTs as needed
 	IR *IRBundle
+	// Profile is the profile merged onto the ASTs, empty for none.
+	Profile string
+	// Paths are the config's paths resolved against its directory.
+	Paths ir.IRPaths
 }
@@ -1,12 +1,48 @@
 package symbols
 
 import (
-	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ConfigDefinition is the root struct for the config HCL file, matching fastify-mongoose.hcl
 type ConfigDefinition struct {
-	Project *ProjectBlock `hcl:"project,block"`
+	Project   *ProjectBlock   `hcl:"project,block"`
+	Lint      *LintBlock      `hcl:"lint,block"`
+	Variables *VariablesBlock `hcl:"variables,block"`
+	Modules   []ModuleBlock   `hcl:"module,block"`
+}
+
+// ModuleBlock imports the schema and service specs of another spec
+// directory; their models, policies and rate limits are referenced with the
+// module name as prefix, e.g. auth.User.
+type ModuleBlock struct {
+	Name   string `hcl:"name,label"`
+	Source string `hcl:"source"` // spec directory, relative to the declaring file
+}
+
+// ModuleSpec is the optional module.hcl of a module directory, declaring the
+// modules it imports in turn.
+type ModuleSpec struct {
+	Modules []ModuleBlock `hcl:"module,block"`
+}
+
+// VariablesBlock declares the inputs var("name") reads, overridable with
+// --var and --var-file.
+type VariablesBlock struct {
+	Variables []Variable `hcl:"variable,block"`
+}
+
+type Variable struct {
+	Name        string    `hcl:"name,label"`
+	Type        string    `hcl:"type,optional"` // string | number | bool
+	Default     cty.Value `hcl:"default,optional"`
+	Description string    `hcl:"description,optional"`
+}
+
+// LintBlock overrides the severity of diagnostic codes, e.g.
+// severity = { "irex.input.recommended" = "off" }.
+type LintBlock struct {
+	Severity map[string]string `hcl:"severity,optional"`
 }
 
 type ProjectBlock struct {
@@ -57,9 +93,11 @@ type RuntimeSchemaBlock struct {
 	Options   *RuntimeSchemaOptions `hcl:"options,block"`
 }
 
+// RuntimeSchemaOptions values are literals or env() references, see
+// functions.DecodeValue.
 type RuntimeSchemaOptions struct {
-	URI functions.EnvRef `hcl:"uri,optional"`
-	DB  functions.EnvRef `hcl:"db,optional"`
+	URI cty.Value `hcl:"uri,optional"`
+	DB  cty.Value `hcl:"db,optional"`
 }
 
 type RuntimeServiceBlock struct {
@@ -1,6 +1,7 @@
 package symbols
 
 import (
+	"github.com/hashicorp/hcl/v2"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -66,6 +67,8 @@ type MongoDBConfig struct {
 	AutoIndex     bool   `hcl:"autoIndex,optional"`
 	AutoCreate    bool   `hcl:"autoCreate,optional"`
 	StrictQuery   bool   `hcl:"strictQuery,optional"`
+	// Body tells the attributes set to false apart from the absent ones.
+	Body hcl.Body `hcl:",body"`
 }
 
 type MySqlDBConfig struct {
@@ -86,6 +89,8 @@ type ModelConfig struct {
 	IDStrategy  string         `hcl:"idStrategy,optional"`
 	Description string         `hcl:"description,optional"`
 	DB          *ModelConfigDB `hcl:"db,block"`
+	// Body tells the attributes set to false apart from the absent ones.
+	Body hcl.Body `hcl:",body"`
 }
 
 type ManyToManyBlock struct {
@@ -117,15 +122,32 @@ type Relations struct {
 
 type Model struct {
 	Name      string       `hcl:"name,label"`
+	Include   []string     `hcl:"include,optional"` // mixins whose fields and config the model takes
 	Fields    []ModelField `hcl:"field,block"`
 	Config    *ModelConfig `hcl:"config,block"`
 	Relations *Relations   `hcl:"relations,block"`
 }
 
+// Mixin is a reusable set of fields and config that models pull in with
+// include = ["name"].
+type Mixin struct {
+	Name   string       `hcl:"name,label"`
+	Fields []ModelField `hcl:"field,block"`
+	Config *ModelConfig `hcl:"config,block"`
+}
+
+// LocalsBlock holds `name = expression` values read as local.name. Locals
+// are evaluated before the rest of the spec, so the body is kept raw.
+type LocalsBlock struct {
+	Body hcl.Body `hcl:",remain"`
+}
+
 type ModelsBlock struct {
 	Models []Model `hcl:"model,block"`
 }
 
 type ModelsSpec struct {
-	ModelsBlock *ModelsBlock `hcl:"models,block"`
+	Locals      []LocalsBlock `hcl:"locals,block"`
+	Mixins      []Mixin       `hcl:"mixin,block"`
+	ModelsBlock *ModelsBlock  `hcl:"models,block"`
 }
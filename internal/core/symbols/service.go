@@ -1,5 +1,9 @@
 package symbols
 
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
 // ServiceDefinition is the root struct for the services.hcl file
 type ServiceDefinition struct {
 	Policies   *PoliciesBlock   `hcl:"policies,block"`
@@ -96,16 +100,27 @@ type ServicesBlock struct {
 	Services   []Service        `hcl:"service,block"`
 }
 
+// ServiceDefaults holds the settings services inherit. The *Set attributes
+// take a list or one of only(), except(), with() and without() (see
+// functions.DecodeSet); normalize resolves them into the matching []string
+// fields against the inherited values.
 type ServiceDefaults struct {
-	Pagination      *bool    `hcl:"pagination,optional"`
-	Expose          *bool    `hcl:"expose,optional"`
-	SoftDelete      *bool    `hcl:"soft_delete,optional"`
-	CrudOperations  []string `hcl:"crud_operations,optional"`
-	BatchOperations []string `hcl:"batch_operations,optional"`
-	Middlewares     []string `hcl:"middlewares,optional"`
-	Sorting         []string `hcl:"sorting,optional"`
-	Filtering       []string `hcl:"filtering,optional"`
-	Search          []string `hcl:"search,optional"`
+	Pagination         *bool     `hcl:"pagination,optional"`
+	Expose             *bool     `hcl:"expose,optional"`
+	SoftDelete         *bool     `hcl:"soft_delete,optional"`
+	CrudOperationsSet  cty.Value `hcl:"crud_operations,optional"`
+	BatchOperationsSet cty.Value `hcl:"batch_operations,optional"`
+	MiddlewaresSet     cty.Value `hcl:"middlewares,optional"`
+	SortingSet         cty.Value `hcl:"sorting,optional"`
+	FilteringSet       cty.Value `hcl:"filtering,optional"`
+	Search             []string  `hcl:"search,optional"`
+
+	// resolved by normalize.NormalizeServiceAST
+	CrudOperations  []string
+	BatchOperations []string
+	Middlewares     []string
+	Sorting         []string
+	Filtering       []string
 }
 
 type Operation struct {
@@ -118,20 +133,25 @@ type Operation struct {
 }
 
 type Service struct {
-	Name            string            `hcl:"name,label"`
-	Model           string            `hcl:"model,optional"`
-	Expose          *bool             `hcl:"expose,optional"`
-	Pagination      *bool             `hcl:"pagination,optional"`
-	Path            string            `hcl:"path,optional"`
-	CrudOperations  []string          `hcl:"crud_operations,optional"`
-	BatchOperations []string          `hcl:"batch_operations,optional"`
-	Middlewares     []string          `hcl:"middlewares,optional"`
-	Policies        []string          `hcl:"policies,optional"`
-	RateLimit       *ServiceRateLimit `hcl:"rate_limit,block"`
-	Apply           []ApplyBlock      `hcl:"apply,block"`
-	Operations      []Operation       `hcl:"operation,block"`
-	Services        []Service         `hcl:"service,block"`
-	Defaults        *ServiceDefaults  `hcl:"defaults,block"`
+	Name               string            `hcl:"name,label"`
+	Model              string            `hcl:"model,optional"`
+	Expose             *bool             `hcl:"expose,optional"`
+	Pagination         *bool             `hcl:"pagination,optional"`
+	Path               string            `hcl:"path,optional"`
+	CrudOperationsSet  cty.Value         `hcl:"crud_operations,optional"`
+	BatchOperationsSet cty.Value         `hcl:"batch_operations,optional"`
+	MiddlewaresSet     cty.Value         `hcl:"middlewares,optional"`
+	Policies           []string          `hcl:"policies,optional"`
+	RateLimit          *ServiceRateLimit `hcl:"rate_limit,block"`
+	Apply              []ApplyBlock      `hcl:"apply,block"`
+	Operations         []Operation       `hcl:"operation,block"`
+	Services           []Service         `hcl:"service,block"`
+	Defaults           *ServiceDefaults  `hcl:"defaults,block"`
+
+	// resolved by normalize.NormalizeServiceAST
+	CrudOperations  []string
+	BatchOperations []string
+	Middlewares     []string
 }
 
 type ServiceRateLimit struct {
@@ -1,6 +1,10 @@
 package validate
 
 import (
+	"sort"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/functions"
 	"github.com/kwizyHQ/irex/internal/core/symbols"
 	"github.com/kwizyHQ/irex/internal/diagnostics"
 )
@@ -11,107 +15,164 @@ func ValidateConfig(cfg *symbols.ConfigDefinition) []Diagnostic {
 	zeroRange := diagnostics.Range{}
 
 	if cfg.Project == nil {
-		reporter.Error("Missing required 'project' block.", zeroRange, "irex.input.required", "project")
+		reporter.Error("Missing required 'project' block.", zeroRange, diagnostics.CodeInputRequired, "project")
 		return reporter.All()
 	}
 	p := cfg.Project
 
 	if p.Name == "" {
-		reporter.Error("Project 'name' is required.", zeroRange, "irex.input.required", "project.name")
+		reporter.Error("Project 'name' is required.", zeroRange, diagnostics.CodeInputRequired, "project.name")
 	}
 	if p.Version == "" {
-		reporter.Error("Project 'version' is required.", zeroRange, "irex.input.required", "project.version")
+		reporter.Error("Project 'version' is required.", zeroRange, diagnostics.CodeInputRequired, "project.version")
 	}
 	if p.Author == "" {
-		reporter.Warn("Project 'author' is required.", zeroRange, "irex.input.required", "project.author")
+		reporter.Warn("Project 'author' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.author")
 	}
 	if p.License == "" {
-		reporter.Warn("Project 'license' is required.", zeroRange, "irex.input.required", "project.license")
+		reporter.Warn("Project 'license' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.license")
 	}
 
 	if p.Paths == nil {
-		reporter.Error("Missing required 'paths' block.", zeroRange, "irex.input.required", "project.paths")
+		reporter.Error("Missing required 'paths' block.", zeroRange, diagnostics.CodeInputRequired, "project.paths")
 	} else {
 		if p.Paths.Specifications == "" {
-			reporter.Error("'paths.specifications' is required.", zeroRange, "irex.input.required", "project.paths.specifications")
+			reporter.Error("'paths.specifications' is required.", zeroRange, diagnostics.CodeInputRequired, "project.paths.specifications")
 		}
 		if p.Paths.Templates == "" {
-			reporter.Warn("'paths.templates' is required.", zeroRange, "irex.input.required", "project.paths.templates")
+			reporter.Warn("'paths.templates' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.paths.templates")
 		}
 		if p.Paths.Output == "" {
-			reporter.Error("'paths.output' is required.", zeroRange, "irex.input.required", "project.paths.output")
+			reporter.Error("'paths.output' is required.", zeroRange, diagnostics.CodeInputRequired, "project.paths.output")
 		}
 	}
 
 	if p.Generator == nil {
-		reporter.Error("Missing required 'generator' block.", zeroRange, "irex.input.required", "project.generator")
+		reporter.Error("Missing required 'generator' block.", zeroRange, diagnostics.CodeInputRequired, "project.generator")
 	}
 
 	if p.Runtime == nil {
-		reporter.Error("Missing required 'runtime' block.", zeroRange, "irex.input.required", "project.runtime")
+		reporter.Error("Missing required 'runtime' block.", zeroRange, diagnostics.CodeInputRequired, "project.runtime")
 	} else {
 		if p.Runtime.Name == "" {
-			reporter.Error("'runtime.name' is required.", zeroRange, "irex.input.required", "project.runtime.name")
+			reporter.Error("'runtime.name' is required.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.name")
 		}
 		if p.Runtime.Version == "" {
-			reporter.Warn("'runtime.version' is required.", zeroRange, "irex.input.required", "project.runtime.version")
+			reporter.Warn("'runtime.version' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.runtime.version")
 		}
 		if p.Runtime.Options == nil {
-			reporter.Error("Missing required 'runtime.options' block.", zeroRange, "irex.input.required", "project.runtime.options")
+			reporter.Error("Missing required 'runtime.options' block.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.options")
 		} else {
 			if p.Runtime.Options.PackageManager == "" {
-				reporter.Warn("'runtime.options.package_manager' is required.", zeroRange, "irex.input.required", "project.runtime.options.package_manager")
+				reporter.Warn("'runtime.options.package_manager' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.runtime.options.package_manager")
 			}
 			if p.Runtime.Options.Entry == "" {
-				reporter.Warn("'runtime.options.entry' is required.", zeroRange, "irex.input.required", "project.runtime.options.entry")
+				reporter.Warn("'runtime.options.entry' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.runtime.options.entry")
 			}
 		}
 		if p.Runtime.Schema == nil {
-			reporter.Error("Missing required 'runtime.schema' block.", zeroRange, "irex.input.required", "project.runtime.schema")
+			reporter.Error("Missing required 'runtime.schema' block.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.schema")
 		} else {
 			if p.Runtime.Schema.Framework == "" {
-				reporter.Error("'runtime.schema.framework' is required.", zeroRange, "irex.input.required", "project.runtime.schema.framework")
+				reporter.Error("'runtime.schema.framework' is required.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.schema.framework")
 			}
 			if p.Runtime.Schema.Options == nil {
-				reporter.Error("Missing required 'runtime.schema.options' block.", zeroRange, "irex.input.required", "project.runtime.schema.options")
+				reporter.Error("Missing required 'runtime.schema.options' block.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.schema.options")
 			} else {
-				// if p.Runtime.Schema.Options.URI == "" {
-				// 	reporter.Warn("'runtime.schema.options.uri' is required.", zeroRange, "irex.input.required", "project.runtime.schema.options.uri")
-				// }
-				// if p.Runtime.Schema.Options.DB == "" {
-				// 	reporter.Warn("'runtime.schema.options.db' is required.", zeroRange, "irex.input.required", "project.runtime.schema.options.db")
-				// }
+				if _, err := functions.DecodeValue(p.Runtime.Schema.Options.URI); err != nil {
+					reporter.Error("'runtime.schema.options.uri': "+err.Error()+".", zeroRange, diagnostics.CodeInputInvalid, "project.runtime.schema.options.uri")
+				}
+				if _, err := functions.DecodeValue(p.Runtime.Schema.Options.DB); err != nil {
+					reporter.Error("'runtime.schema.options.db': "+err.Error()+".", zeroRange, diagnostics.CodeInputInvalid, "project.runtime.schema.options.db")
+				}
 			}
 		}
 		if p.Runtime.Service == nil {
-			reporter.Error("Missing required 'runtime.service' block.", zeroRange, "irex.input.required", "project.runtime.service")
+			reporter.Error("Missing required 'runtime.service' block.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.service")
 		} else {
 			if p.Runtime.Service.Framework == "" {
-				reporter.Error("'runtime.service.framework' is required.", zeroRange, "irex.input.required", "project.runtime.service.framework")
+				reporter.Error("'runtime.service.framework' is required.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.service.framework")
 			}
 			if p.Runtime.Service.Options == nil {
-				reporter.Error("Missing required 'runtime.service.options' block.", zeroRange, "irex.input.required", "project.runtime.service.options")
+				reporter.Error("Missing required 'runtime.service.options' block.", zeroRange, diagnostics.CodeInputRequired, "project.runtime.service.options")
 			} else {
 				if p.Runtime.Service.Options.Port == 0 {
-					reporter.Warn("'runtime.service.options.port' is required and must be > 0.", zeroRange, "irex.input.required", "project.runtime.service.options.port")
+					reporter.Warn("'runtime.service.options.port' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.runtime.service.options.port")
 				}
 				if p.Runtime.Service.Options.Host == "" {
-					reporter.Warn("'runtime.service.options.host' is required.", zeroRange, "irex.input.required", "project.runtime.service.options.host")
+					reporter.Warn("'runtime.service.options.host' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.runtime.service.options.host")
 				}
 			}
 		}
 	}
 
 	if p.Meta == nil {
-		reporter.Info("Missing optional 'meta' block.", zeroRange, "irex.input.recommended", "project.meta")
+		reporter.Info("Missing optional 'meta' block.", zeroRange, diagnostics.CodeInputRecommended, "project.meta")
 	} else {
 		if p.Meta.CreatedAt == "" {
-			reporter.Info("'meta.created_at' is recommended.", zeroRange, "irex.input.recommended", "project.meta.created_at")
+			reporter.Info("'meta.created_at' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.meta.created_at")
 		}
 		if p.Meta.GeneratorVersion == "" {
-			reporter.Info("'meta.generator_version' is recommended.", zeroRange, "irex.input.recommended", "project.meta.generator_version")
+			reporter.Info("'meta.generator_version' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "project.meta.generator_version")
 		}
 	}
 
+	if cfg.Lint != nil {
+		validateLint(cfg.Lint, reporter)
+	}
+	reporter.Extend(ValidateModules(cfg.Modules))
+
+	return reporter.All()
+}
+
+// ValidateModules checks the module blocks of irex.hcl or a module.hcl:
+// names must be unique and usable as a reference prefix.
+func ValidateModules(modules []symbols.ModuleBlock) []Diagnostic {
+	reporter := diagnostics.NewReporter()
+	zeroRange := diagnostics.Range{}
+	seen := map[string]struct{}{}
+	for _, m := range modules {
+		path := "module." + m.Name
+		switch {
+		case m.Name == "":
+			reporter.Error("Module name is required.", zeroRange, diagnostics.CodeInputRequired, "module")
+			continue
+		case strings.Contains(m.Name, "."):
+			reporter.Error("Module name '"+m.Name+"' must not contain '.', it prefixes the module's symbols.", zeroRange, diagnostics.CodeInputInvalid, path)
+		}
+		if _, exists := seen[m.Name]; exists {
+			reporter.Error("Duplicate module name: "+m.Name, zeroRange, diagnostics.CodeInputDuplicate, path)
+			reporter.Relate(diagnostics.Related{Message: "First defined here", HclPath: path, First: true})
+			continue
+		}
+		seen[m.Name] = struct{}{}
+		if m.Source == "" {
+			reporter.Error("Module '"+m.Name+"' needs a 'source' directory.", zeroRange, diagnostics.CodeInputRequired, path+".source")
+		}
+	}
 	return reporter.All()
 }
+
+// validateLint checks that severity overrides name known codes and severities,
+// and that they leave error codes at error.
+func validateLint(l *symbols.LintBlock, reporter *diagnostics.Reporter) {
+	zeroRange := diagnostics.Range{}
+	codes := make([]string, 0, len(l.Severity))
+	for code := range l.Severity {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if _, ok := diagnostics.LookupCode(code); !ok {
+			reporter.Warn("Unknown diagnostic code '"+code+"' in lint severity overrides.", zeroRange, diagnostics.CodeInputIgnored, "lint.severity")
+		}
+		s, err := diagnostics.ParseSeverity(l.Severity[code])
+		if err != nil {
+			reporter.Error("Lint override for '"+code+"': "+err.Error()+".", zeroRange, diagnostics.CodeInputInvalid, "lint.severity")
+			continue
+		}
+		if _, ok := diagnostics.LookupCode(code); ok && !diagnostics.Suppressible(code) && s != diagnostics.SeverityError {
+			reporter.Error("Lint override for '"+code+"': error codes cannot be set to '"+l.Severity[code]+"'.", zeroRange, diagnostics.CodeInputInvalid, "lint.severity")
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package validate
 
 import (
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/functions"
 	"github.com/kwizyHQ/irex/internal/core/symbols"
 	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ValidateService performs semantic checks on a ServiceDefinition and returns diagnostics for all issues found.
@@ -11,93 +15,44 @@ func ValidateService(def *symbols.ServiceDefinition) []Diagnostic {
 	zeroRange := diagnostics.Range{}
 
 	if def == nil {
-		reporter.Error("Missing service definition root block.", zeroRange, "irex.input.required", "service")
+		reporter.Error("Missing service definition root block.", zeroRange, diagnostics.CodeInputRequired, "service")
 		return reporter.All()
 	}
 
 	// --- POLICIES ---
-	if def.Policies == nil {
-		reporter.Error("Missing required 'policies' block.", zeroRange, "irex.input.required", "service.policies")
-	} else {
-		presetNames := map[string]struct{}{}
-		for _, p := range def.Policies.Presets {
-			if p.Name == "" {
-				reporter.Error("Policy preset missing name.", zeroRange, "irex.input.required", "service.policies.preset.name")
-			} else {
-				if _, exists := presetNames[p.Name]; exists {
-					reporter.Error("Duplicate policy preset name: "+p.Name, zeroRange, "irex.input.duplicate", "service.policies.preset.name")
-				} else {
-					presetNames[p.Name] = struct{}{}
-				}
-			}
-			if p.Scope == "" {
-				reporter.Warn("Policy preset '"+p.Name+"' missing scope.", zeroRange, "irex.input.recommended", "service.policies.preset.scope")
-			}
-		}
-		for _, c := range def.Policies.Customs {
-			if c.Name == "" {
-				reporter.Error("Custom policy missing name.", zeroRange, "irex.input.required", "service.policies.custom.name")
-			}
-		}
-		for _, g := range def.Policies.Groups {
-			if g.Name == "" {
-				reporter.Error("Policy group missing name.", zeroRange, "irex.input.required", "service.policies.group.name")
-			}
-			if g.Scope == "" {
-				reporter.Error("Policy group '"+g.Name+"' missing scope.", zeroRange, "irex.input.required", "service.policies.group.scope")
-			}
-			if len(g.Policies) == 0 {
-				reporter.Warn("Policy group '"+g.Name+"' has no policies.", zeroRange, "irex.input.recommended", "service.policies.group.policies")
-			}
-		}
+	// a spec without policies or rate limits may leave their blocks out
+	if def.Policies != nil {
+		checkPolicies(def.Policies, reporter)
 	}
 
 	// --- RATE LIMITS ---
-	if def.RateLimits == nil {
-		reporter.Error("Missing required 'rate_limits' block.", zeroRange, "irex.input.required", "service.rate_limits")
-	} else {
-		presetNames := map[string]struct{}{}
-		for _, p := range def.RateLimits.Presets {
-			if p.Name == "" {
-				reporter.Error("Rate limit preset missing name.", zeroRange, "irex.input.required", "service.rate_limits.preset.name")
-			} else {
-				if _, exists := presetNames[p.Name]; exists {
-					reporter.Error("Duplicate rate limit preset name: "+p.Name, zeroRange, "irex.input.duplicate", "service.rate_limits.preset.name")
-				} else {
-					presetNames[p.Name] = struct{}{}
-				}
-			}
-			if p.Limit == "" && p.Type != "token_bucket" {
-				reporter.Warn("Rate limit preset '"+p.Name+"' missing limit.", zeroRange, "irex.input.recommended", "service.rate_limits.preset.limit")
-			}
-		}
-		for _, c := range def.RateLimits.Customs {
-			if c.Name == "" {
-				reporter.Error("Custom rate limit missing name.", zeroRange, "irex.input.required", "service.rate_limits.custom.name")
-			}
-		}
+	if def.RateLimits != nil {
+		checkRateLimits(def.RateLimits, reporter)
 	}
 
 	// --- SERVICES ---
 	if def.Services == nil {
-		reporter.Error("Missing required 'services' block.", zeroRange, "irex.input.required", "service.services")
+		reporter.Error("Missing required 'services' block.", zeroRange, diagnostics.CodeInputRequired, "services")
 	} else {
 		if def.Services.BasePath == "" {
-			reporter.Warn("Global 'base_path' is recommended.", zeroRange, "irex.input.recommended", "service.services.base_path")
+			reporter.Warn("Global 'base_path' is recommended.", zeroRange, diagnostics.CodeInputRecommended, "services.base_path")
+		}
+		if def.Services.Defaults != nil {
+			checkDefaultSets(*def.Services.Defaults, reporter, "services.defaults")
 		}
-		serviceNames := map[string]struct{}{}
+		serviceNames := map[string]string{} // name -> block path of its first definition
 		for _, svc := range def.Services.Services {
-			checkServiceBlockSemantics(svc, reporter, zeroRange, serviceNames)
+			checkServiceBlockSemantics(svc, reporter, zeroRange, serviceNames, "services")
 		}
 		for _, op := range def.Services.Operations {
 			if op.Name == "" {
-				reporter.Error("Global operation missing name.", zeroRange, "irex.input.required", "service.services.operation.name")
+				reporter.Error("Global operation missing name.", zeroRange, diagnostics.CodeInputRequired, "services")
 			}
 			if op.Method == "" {
-				reporter.Warn("Operation '"+op.Name+"' missing method.", zeroRange, "irex.input.recommended", "service.services.operation.method")
+				reporter.Warn("Operation '"+op.Name+"' missing method.", zeroRange, diagnostics.CodeInputRecommended, "services.operation."+op.Name+".method")
 			}
 			if op.Path == "" {
-				reporter.Warn("Operation '"+op.Name+"' missing path.", zeroRange, "irex.input.recommended", "service.services.operation.path")
+				reporter.Warn("Operation '"+op.Name+"' missing path.", zeroRange, diagnostics.CodeInputRecommended, "services.operation."+op.Name+".path")
 			}
 		}
 	}
@@ -105,34 +60,155 @@ func ValidateService(def *symbols.ServiceDefinition) []Diagnostic {
 	return reporter.All()
 }
 
-func checkServiceBlockSemantics(svc symbols.Service, reporter *diagnostics.Reporter, rng diagnostics.Range, serviceNames map[string]struct{}) {
+// checkServiceBlockSemantics validates svc and its children. prefix is the
+// HCL block path of the enclosing block (e.g. "services").
+func checkServiceBlockSemantics(svc symbols.Service, reporter *diagnostics.Reporter, rng diagnostics.Range, serviceNames map[string]string, prefix string) {
 	if svc.Name == "" {
-		reporter.Error("Service block missing name.", rng, "irex.input.required", "service.services.service.name")
+		reporter.Error("Service block missing name.", rng, diagnostics.CodeInputRequired, prefix)
 		return
 	}
-	if _, exists := serviceNames[svc.Name]; exists {
-		reporter.Error("Duplicate service name: "+svc.Name, rng, "irex.input.duplicate", "service.services.service.name")
+	blockPath := prefix + ".service." + svc.Name
+	if first, exists := serviceNames[svc.Name]; exists {
+		reporter.Error("Duplicate service name: "+svc.Name, rng, diagnostics.CodeInputDuplicate, blockPath)
+		reporter.Relate(diagnostics.Related{Message: "First defined here", HclPath: first, First: true})
 	} else {
-		serviceNames[svc.Name] = struct{}{}
+		serviceNames[svc.Name] = blockPath
 	}
 	if svc.Model == "" {
-		reporter.Warn("Service '"+svc.Name+"' missing model.", rng, "irex.input.recommended", "service.services.service.model")
+		reporter.Warn("Service '"+svc.Name+"' missing model.", rng, diagnostics.CodeInputRecommended, blockPath+".model")
 	}
 	if svc.Path == "" {
-		reporter.Warn("Service '"+svc.Name+"' missing path.", rng, "irex.input.recommended", "service.services.service.path")
+		reporter.Warn("Service '"+svc.Name+"' missing path.", rng, diagnostics.CodeInputRecommended, blockPath+".path")
+	}
+	checkSet(svc.CrudOperationsSet, reporter, blockPath+".crud_operations")
+	checkSet(svc.BatchOperationsSet, reporter, blockPath+".batch_operations")
+	checkSet(svc.MiddlewaresSet, reporter, blockPath+".middlewares")
+	if svc.Defaults != nil {
+		checkDefaultSets(*svc.Defaults, reporter, blockPath+".defaults")
 	}
 	for _, op := range svc.Operations {
 		if op.Name == "" {
-			reporter.Error("Operation in service '"+svc.Name+"' missing name.", rng, "irex.input.required", "service.services.service.operation.name")
+			reporter.Error("Operation in service '"+svc.Name+"' missing name.", rng, diagnostics.CodeInputRequired, blockPath)
 		}
 		if op.Method == "" {
-			reporter.Warn("Operation '"+op.Name+"' in service '"+svc.Name+"' missing method.", rng, "irex.input.recommended", "service.services.service.operation.method")
+			reporter.Warn("Operation '"+op.Name+"' in service '"+svc.Name+"' missing method.", rng, diagnostics.CodeInputRecommended, blockPath+".operation."+op.Name+".method")
 		}
 		if op.Path == "" {
-			reporter.Warn("Operation '"+op.Name+"' in service '"+svc.Name+"' missing path.", rng, "irex.input.recommended", "service.services.service.operation.path")
+			reporter.Warn("Operation '"+op.Name+"' in service '"+svc.Name+"' missing path.", rng, diagnostics.CodeInputRecommended, blockPath+".operation."+op.Name+".path")
 		}
 	}
 	for _, child := range svc.Services {
-		checkServiceBlockSemantics(child, reporter, rng, serviceNames)
+		checkServiceBlockSemantics(child, reporter, rng, serviceNames, blockPath)
+	}
+}
+
+// checkDefaultSets validates the list-or-set attributes of a defaults block at path.
+func checkDefaultSets(d symbols.ServiceDefaults, reporter *diagnostics.Reporter, path string) {
+	checkSet(d.CrudOperationsSet, reporter, path+".crud_operations")
+	checkSet(d.BatchOperationsSet, reporter, path+".batch_operations")
+	checkSet(d.MiddlewaresSet, reporter, path+".middlewares")
+	checkSet(d.SortingSet, reporter, path+".sorting")
+	checkSet(d.FilteringSet, reporter, path+".filtering")
+}
+
+var crudOperationNames = map[string]struct{}{"create": {}, "read": {}, "update": {}, "delete": {}, "list": {}, "*": {}}
+
+// checkSet reports an attribute at path that is neither a list of strings
+// nor a set function, and unknown names in crud_operations.
+func checkSet(v cty.Value, reporter *diagnostics.Reporter, path string) {
+	set, ok, err := functions.DecodeSet(v)
+	name := path[strings.LastIndex(path, ".")+1:]
+	if err != nil {
+		reporter.Error("Invalid '"+name+"': "+err.Error()+".", diagnostics.Range{}, diagnostics.CodeInputInvalid, path)
+		return
+	}
+	if !ok || name != "crud_operations" {
+		return
+	}
+	for _, op := range append(append([]string{}, set.Include...), set.Exclude...) {
+		if _, known := crudOperationNames[strings.ToLower(op)]; !known {
+			reporter.Warn("Unknown CRUD operation '"+op+"'; expected create, read, update, delete or list.", diagnostics.Range{}, diagnostics.CodeInputIgnored, path)
+		}
+	}
+}
+
+// ValidateModuleService checks a service spec of an imported module. Modules
+// share policies and rate limits, so both blocks are optional, and their
+// services are not imported.
+func ValidateModuleService(def *symbols.ServiceDefinition) []Diagnostic {
+	reporter := diagnostics.NewReporter()
+	if def.Policies != nil {
+		checkPolicies(def.Policies, reporter)
+	}
+	if def.RateLimits != nil {
+		checkRateLimits(def.RateLimits, reporter)
+	}
+	if def.Services != nil {
+		reporter.Warn("Services declared in a module are not imported; only its policies and rate limits are.",
+			diagnostics.Range{}, diagnostics.CodeInputIgnored, "services")
+	}
+	return reporter.All()
+}
+
+// checkPolicies validates the presets, customs and groups of a policies block.
+func checkPolicies(block *symbols.PoliciesBlock, reporter *diagnostics.Reporter) {
+	zeroRange := diagnostics.Range{}
+	presetNames := map[string]struct{}{}
+	for _, p := range block.Presets {
+		if p.Name == "" {
+			reporter.Error("Policy preset missing name.", zeroRange, diagnostics.CodeInputRequired, "policies")
+		} else {
+			if _, exists := presetNames[p.Name]; exists {
+				reporter.Error("Duplicate policy preset name: "+p.Name, zeroRange, diagnostics.CodeInputDuplicate, "policies.policy."+p.Name)
+				reporter.Relate(diagnostics.Related{Message: "First defined here", HclPath: "policies.policy." + p.Name, First: true})
+			} else {
+				presetNames[p.Name] = struct{}{}
+			}
+		}
+		if p.Scope == "" {
+			reporter.Warn("Policy preset '"+p.Name+"' missing scope.", zeroRange, diagnostics.CodeInputRecommended, "policies.policy."+p.Name+".scope")
+		}
+	}
+	for _, c := range block.Customs {
+		if c.Name == "" {
+			reporter.Error("Custom policy missing name.", zeroRange, diagnostics.CodeInputRequired, "policies")
+		}
+	}
+	for _, g := range block.Groups {
+		if g.Name == "" {
+			reporter.Error("Policy group missing name.", zeroRange, diagnostics.CodeInputRequired, "policies")
+		}
+		if g.Scope == "" {
+			reporter.Error("Policy group '"+g.Name+"' missing scope.", zeroRange, diagnostics.CodeInputRequired, "policies.group."+g.Name+".scope")
+		}
+		if len(g.Policies) == 0 {
+			reporter.Warn("Policy group '"+g.Name+"' has no policies.", zeroRange, diagnostics.CodeInputRecommended, "policies.group."+g.Name+".policies")
+		}
+	}
+}
+
+// checkRateLimits validates the presets and customs of a rate_limits block.
+func checkRateLimits(block *symbols.RateLimitsBlock, reporter *diagnostics.Reporter) {
+	zeroRange := diagnostics.Range{}
+	presetNames := map[string]struct{}{}
+	for _, p := range block.Presets {
+		if p.Name == "" {
+			reporter.Error("Rate limit preset missing name.", zeroRange, diagnostics.CodeInputRequired, "rate_limits")
+		} else {
+			if _, exists := presetNames[p.Name]; exists {
+				reporter.Error("Duplicate rate limit preset name: "+p.Name, zeroRange, diagnostics.CodeInputDuplicate, "rate_limits.preset."+p.Name)
+				reporter.Relate(diagnostics.Related{Message: "First defined here", HclPath: "rate_limits.preset." + p.Name, First: true})
+			} else {
+				presetNames[p.Name] = struct{}{}
+			}
+		}
+		if p.Limit == "" && p.Type != "token_bucket" {
+			reporter.Warn("Rate limit preset '"+p.Name+"' missing limit.", zeroRange, diagnostics.CodeInputRecommended, "rate_limits.preset."+p.Name+".limit")
+		}
+	}
+	for _, c := range block.Customs {
+		if c.Name == "" {
+			reporter.Error("Custom rate limit missing name.", zeroRange, diagnostics.CodeInputRequired, "rate_limits")
+		}
 	}
 }
@@ -10,7 +10,7 @@ func ValidateTemplates(def *symbols.TemplateDefinition) []diagnostics.Diagnostic
 	zeroRange := diagnostics.Range{}
 
 	if def == nil {
-		reporter.Error("Missing template definition root block.", zeroRange, "irex.input.required", "template")
+		reporter.Error("Missing template definition root block.", zeroRange, diagnostics.CodeInputRequired, "template")
 		return reporter.All()
 	}
 
@@ -18,10 +18,10 @@ func ValidateTemplates(def *symbols.TemplateDefinition) []diagnostics.Diagnostic
 	templateNames := map[string]struct{}{}
 	for _, t := range def.Templates {
 		if t.Name == "" {
-			reporter.Error("Template missing name.", zeroRange, "irex.input.required", "template.name")
+			reporter.Error("Template missing name.", zeroRange, diagnostics.CodeInputRequired, "template.name")
 		} else {
 			if _, exists := templateNames[t.Name]; exists {
-				reporter.Error("Duplicate template name: "+t.Name, zeroRange, "irex.input.duplicate", "template.name")
+				reporter.Error("Duplicate template name: "+t.Name, zeroRange, diagnostics.CodeInputDuplicate, "template.name")
 			} else {
 				templateNames[t.Name] = struct{}{}
 			}
@@ -31,15 +31,15 @@ func ValidateTemplates(def *symbols.TemplateDefinition) []diagnostics.Diagnostic
 	// check for mode validity (valid modes: "single", "per-item")
 	for _, t := range def.Templates {
 		if t.Mode != "" && t.Mode != "single" && t.Mode != "per-item" {
-			reporter.Error("Invalid template mode '"+t.Mode+"' for template '"+t.Name+"'. Valid modes are 'single' and 'per-item'.", zeroRange, "irex.input.invalid", "template.mode")
+			reporter.Error("Invalid template mode '"+t.Mode+"' for template '"+t.Name+"'. Valid modes are 'single' and 'per-item'.", zeroRange, diagnostics.CodeInputInvalid, "template.mode")
 		}
 		// check if data is set
 		if t.Data == "" {
-			reporter.Error("Template '"+t.Name+"' has no data defined.", zeroRange, "irex.input.recommended", "template.data")
+			reporter.Error("Template '"+t.Name+"' has no data defined.", zeroRange, diagnostics.CodeInputRecommended, "template.data")
 		}
 		// check if output is set
 		if t.Output == "" {
-			reporter.Error("Template '"+t.Name+"' has no output defined.", zeroRange, "irex.input.recommended", "template.output")
+			reporter.Error("Template '"+t.Name+"' has no output defined.", zeroRange, diagnostics.CodeInputRecommended, "template.output")
 		}
 	}
 
@@ -0,0 +1,360 @@
+package diagnostics
+
+import "sort"
+
+// Diagnostic codes. Every code reported by the pipeline is listed here and
+// documented in the catalog below, which backs `irex explain`.
+const (
+	CodeInputRequired    = "irex.input.required"
+	CodeInputRecommended = "irex.input.recommended"
+	CodeInputDuplicate   = "irex.input.duplicate"
+	CodeInputInvalid     = "irex.input.invalid"
+	CodeInputMismatch    = "irex.input.mismatch"
+	CodeInputIgnored     = "irex.input.ignored"
+
+	CodeConfigNotFound       = "config.not_found"
+	CodeConfigReadError      = "config.read_error"
+	CodeConfigEnvMissing     = "config.env.missing"
+	CodeConfigProfileUnknown = "config.profile.unknown"
+	CodeConfigModuleCycle    = "config.module.cycle"
+	CodeServiceRead          = "service.read_error"
+	CodeIRBuild              = "ir.build_error"
+
+	CodeSchemaMixinNotFound = "schema.mixin.not_found"
+	CodeSchemaMixinConflict = "schema.mixin.conflict"
+
+	CodeServiceModelNotFound     = "service.model.not_found"
+	CodeServicePolicyNotFound    = "service.policy.not_found"
+	CodeServiceRateLimitNotFound = "service.rate_limit.not_found"
+)
+
+// CodeInfo documents one diagnostic code.
+type CodeInfo struct {
+	Code        string
+	Title       string
+	Severity    Severity // default severity
+	Explanation string
+	Bad         string // HCL that triggers the diagnostic
+	Good        string // the same HCL, fixed
+}
+
+// DocsURL is where the catalog is published; each code has an anchor.
+const DocsURL = "https://github.com/kwizyHQ/irex/blob/main/docs/diagnostics.md"
+
+var catalog = map[string]CodeInfo{
+	CodeInputRequired: {
+		Title:    "Required input is missing",
+		Severity: SeverityError,
+		Explanation: `A block or attribute that the generator needs was not set. The message names
+the missing input; the diagnostic points at the enclosing block. Inputs that
+generation can do without, such as project.author, are reported under
+irex.input.recommended instead.`,
+		Bad: `project {
+  version = "1.0.0"
+}`,
+		Good: `project {
+  name    = "shop"
+  version = "1.0.0"
+}`,
+	},
+	CodeInputRecommended: {
+		Title:    "Recommended input is missing",
+		Severity: SeverityWarning,
+		Explanation: `An optional block or attribute was left out. The project still builds, but
+the generated code falls back to defaults that are usually not what you want
+(for example a policy without an explicit scope, or a project without an
+author or license).`,
+		Bad: `policies {
+  policy "auth" {
+    rule = "ctx.auth != null"
+  }
+}`,
+		Good: `policies {
+  policy "auth" {
+    scope = "request"
+    rule  = "ctx.auth != null"
+  }
+}`,
+	},
+	CodeInputDuplicate: {
+		Title:    "Duplicate name",
+		Severity: SeverityError,
+		Explanation: `Two blocks of the same kind share a name. Models, services, policies,
+rate limits and templates are all looked up by name, so each name must be
+unique within its kind.`,
+		Bad: `models {
+  model "User" {}
+  model "User" {}
+}`,
+		Good: `models {
+  model "User" {}
+  model "Admin" {}
+}`,
+	},
+	CodeInputInvalid: {
+		Title:    "Invalid value",
+		Severity: SeverityError,
+		Explanation: `A value is set but not allowed, either on its own (an unknown template mode)
+or together with another value (a minimum greater than its maximum).`,
+		Bad: `field "name" {
+  type      = "string"
+  minlength = 10
+  maxlength = 2
+}`,
+		Good: `field "name" {
+  type      = "string"
+  minlength = 2
+  maxlength = 10
+}`,
+	},
+	CodeInputMismatch: {
+		Title:    "Conflicting settings",
+		Severity: SeverityWarning,
+		Explanation: `Two settings that should agree do not, for example a unique field whose
+database specific configuration does not create a unique index.`,
+		Bad: `field "email" {
+  type   = "string"
+  unique = true
+  db {
+    mongo {
+      unique = false
+    }
+  }
+}`,
+		Good: `field "email" {
+  type   = "string"
+  unique = true
+}`,
+	},
+	CodeInputIgnored: {
+		Title:    "Input is ignored",
+		Severity: SeverityWarning,
+		Explanation: `A value is accepted but has no effect: an unknown operation in
+crud_operations, the services of an imported module, a variable the variables
+block does not declare, an unknown code in the lint block, or an irex:ignore
+comment without codes or naming an error code, which cannot be ignored. Remove
+it, or correct the name if it is a typo.`,
+		Bad: `service "users" {
+  model           = "User"
+  crud_operations = ["read", "lsit"]
+}`,
+		Good: `service "users" {
+  model           = "User"
+  crud_operations = ["read", "list"]
+}`,
+	},
+	CodeConfigNotFound: {
+		Title:    "File not found",
+		Severity: SeverityError,
+		Explanation: `A file the project refers to does not exist. For irex.hcl, run the command
+from the project root or pass the path to the config file.`,
+	},
+	CodeConfigReadError: {
+		Title:    "File could not be read",
+		Severity: SeverityError,
+		Explanation: `A file exists but reading it failed, usually because of permissions or
+because the path is a directory. The message carries the operating system error.`,
+	},
+	CodeConfigEnvMissing: {
+		Title:    "Environment variable not set",
+		Severity: SeverityWarning,
+		Explanation: `An env() reference has no default and the variable is set neither in the
+environment irex runs in nor in the project's .env file (or .env.vault when
+DOTENV_KEY is set). The generated code reads the variable at runtime, so it
+must be provided there; give env() a second argument to fall back to a default.`,
+		Bad: `options {
+  uri = env("MONGO_URI")
+}`,
+		Good: `options {
+  uri = env("MONGO_URI", "mongodb://localhost:27017")
+}`,
+	},
+	CodeConfigProfileUnknown: {
+		Title:    "Unknown profile",
+		Severity: SeverityError,
+		Explanation: `The profile selected with --profile or IREX_PROFILE is not declared by any
+profile block in irex.hcl or the service specs. Check the spelling, or declare
+the profile; an empty block is enough.`,
+		Bad: `# irex validate --profile production
+profile "prod" {
+  services {
+    base_path = "/api"
+  }
+}`,
+		Good: `# irex validate --profile prod
+profile "prod" {
+  services {
+    base_path = "/api"
+  }
+}`,
+	},
+	CodeConfigModuleCycle: {
+		Title:    "Module import cycle",
+		Severity: SeverityError,
+		Explanation: `A module block imports a spec directory that is already being imported
+further up the chain, directly or through the module.hcl of another module,
+or the project's own specifications directory. Modules must form a tree;
+move the shared specs into a module both sides import.`,
+		Bad: `# shared/auth/module.hcl
+module "billing" {
+  source = "../billing"
+}
+
+# shared/billing/module.hcl
+module "auth" {
+  source = "../auth"
+}`,
+		Good: `# shared/billing/module.hcl
+module "common" {
+  source = "../common"
+}`,
+	},
+	CodeServiceRead: {
+		Title:    "No service files",
+		Severity: SeverityError,
+		Explanation: `The specifications directory has no *.hcl files in its service folder
+(spec/service/*.hcl by default), so there is nothing to generate an API from.`,
+	},
+	CodeIRBuild: {
+		Title:    "Intermediate representation could not be built",
+		Severity: SeverityError,
+		Explanation: `The specification passed validation but assembling the intermediate
+representation failed. This usually points at a combination of settings the
+validators do not catch yet; the message carries the underlying error.`,
+	},
+	CodeSchemaMixinNotFound: {
+		Title:    "Model includes an unknown mixin",
+		Severity: SeverityError,
+		Explanation: `A model's include list must name mixin blocks defined in one of the schema
+files. Names are case sensitive.`,
+		Bad: `models {
+  model "Post" {
+    include = ["Audited"]
+  }
+}`,
+		Good: `mixin "audited" {
+  field "createdBy" { type = "string" }
+}
+
+models {
+  model "Post" {
+    include = ["audited"]
+  }
+}`,
+	},
+	CodeSchemaMixinConflict: {
+		Title:    "Field is defined by both a model and a mixin",
+		Severity: SeverityError,
+		Explanation: `Mixin fields are added to every model that includes the mixin, so a model
+cannot define a field of the same name, and two included mixins cannot both
+define it. Rename or drop one of the fields.`,
+		Bad: `mixin "audited" {
+  field "createdBy" { type = "string" }
+}
+
+models {
+  model "Post" {
+    include = ["audited"]
+    field "createdBy" { type = "string" }
+  }
+}`,
+		Good: `mixin "audited" {
+  field "createdBy" { type = "string" }
+}
+
+models {
+  model "Post" {
+    include = ["audited"]
+    field "title" { type = "string" }
+  }
+}`,
+	},
+	CodeServiceModelNotFound: {
+		Title:    "Service references an unknown model",
+		Severity: SeverityError,
+		Explanation: `A service's model attribute must name a model defined in one of the schema
+files. Names are case sensitive.`,
+		Bad: `service "users" {
+  model = "user"
+}`,
+		Good: `service "users" {
+  model = "User"
+}`,
+	},
+	CodeServicePolicyNotFound: {
+		Title:    "Apply references an unknown policy",
+		Severity: SeverityError,
+		Explanation: `An apply "policy" block must name a policy, group or custom policy defined
+in the policies block.`,
+		Bad: `apply "policy" "admin" {}`,
+		Good: `policies {
+  custom "admin" {}
+}
+
+apply "policy" "admin" {}`,
+	},
+	CodeServiceRateLimitNotFound: {
+		Title:    "Apply references an unknown rate limit",
+		Severity: SeverityError,
+		Explanation: `An apply "rate_limit" block, or the rate_limits list of an apply "policy"
+block, must name a preset or custom rate limit defined in the rate_limits block.`,
+		Bad: `apply "rate_limit" "burst" {}`,
+		Good: `rate_limits {
+  preset "burst" {
+    limit = "10/s"
+  }
+}
+
+apply "rate_limit" "burst" {}`,
+	},
+}
+
+// LookupCode returns the catalog entry for code.
+func LookupCode(code string) (CodeInfo, bool) {
+	info, ok := catalog[code]
+	info.Code = code
+	return info, ok
+}
+
+// Codes returns every catalog entry, sorted by code.
+func Codes() []CodeInfo {
+	out := make([]CodeInfo, 0, len(catalog))
+	for code := range catalog {
+		info, _ := LookupCode(code)
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// Suppressible reports whether lint may turn off, downgrade or ignore code.
+// Codes that are errors by default (parse, reference and required-input
+// problems) stop the build and cannot be silenced.
+func Suppressible(code string) bool {
+	info, ok := catalog[code]
+	return ok && info.Severity != SeverityError
+}
+
+// CodeURL links to the documentation of code, or returns "" for codes that
+// are not in the catalog.
+func CodeURL(code string) string {
+	if _, ok := catalog[code]; !ok {
+		return ""
+	}
+	return DocsURL + "#" + code
+}
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	default:
+		return "unknown"
+	}
+}
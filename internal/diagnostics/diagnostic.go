@@ -22,6 +22,22 @@ type Diagnostic struct {
 	HclPath  string   `json:"hcl_path,omitempty"`
 	Filename string   `json:"filename,omitempty"`
 	Code     string   `json:"code,omitempty"`
+	// Related points at other locations that explain the diagnostic, such
+	// as the first definition of a duplicate.
+	Related []Related `json:"related,omitempty"`
+}
+
+// Related is a secondary location of a Diagnostic. Like a Diagnostic, it is
+// located by HclPath when Range is empty; an empty Filename means the file
+// of its diagnostic.
+type Related struct {
+	Message  string `json:"message"`
+	Range    Range  `json:"range"`
+	HclPath  string `json:"hcl_path,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	// First selects the first block at HclPath rather than the last, for
+	// paths that are defined more than once.
+	First bool `json:"-"`
 }
 
 type Diagnostics []Diagnostic
@@ -43,3 +59,15 @@ func (d Diagnostics) Error() string {
 		return fmt.Sprintf("%s, and %d other diagnostic(s)", d[0].Error(), count-1)
 	}
 }
+
+// MaxSeverity returns the most severe level in d (SeverityError being the
+// highest), or 0 when d is empty.
+func (d Diagnostics) MaxSeverity() Severity {
+	var max Severity
+	for _, diag := range d {
+		if max == 0 || diag.Severity < max {
+			max = diag.Severity
+		}
+	}
+	return max
+}
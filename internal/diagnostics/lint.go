@@ -0,0 +1,118 @@
+package diagnostics
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// SeverityOff silences a code entirely when used as a lint override.
+const SeverityOff Severity = -1
+
+// Ignore is an inline `# irex:ignore` suppression. It covers the diagnostics
+// of one file that carry one of Codes and whose HclPath is Path or lies below
+// it, or whose range starts within StartLine..EndLine (1-based).
+type Ignore struct {
+	Codes     []string
+	Path      string
+	StartLine int
+	EndLine   int
+}
+
+func (ig Ignore) matches(d Diagnostic) bool {
+	if !slices.Contains(ig.Codes, d.Code) {
+		return false
+	}
+	if ig.Path != "" && d.HclPath != "" && (d.HclPath == ig.Path || strings.HasPrefix(d.HclPath, ig.Path+".")) {
+		return true
+	}
+	line := d.Range.Start.Line
+	return line > 0 && line >= ig.StartLine && line <= ig.EndLine
+}
+
+// Lint holds the project's severity overrides (the `lint` block of irex.hcl)
+// and the inline suppressions of each file. A Reporter with a Lint applies it
+// whenever diagnostics are read, so the CLI and the LSP see the same result.
+// Errors are never hidden or downgraded, and a code raised to error stops
+// the build like any other error.
+// Lint is safe for concurrent use; a nil *Lint changes nothing.
+type Lint struct {
+	mu        sync.Mutex
+	overrides map[string]Severity
+	ignores   map[string][]Ignore // by filename
+}
+
+func NewLint() *Lint {
+	return &Lint{
+		overrides: make(map[string]Severity),
+		ignores:   make(map[string][]Ignore),
+	}
+}
+
+// ParseSeverity reads a lint severity: error, warn (or warning), info, hint or off.
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(s) {
+	case "error":
+		return SeverityError, nil
+	case "warn", "warning":
+		return SeverityWarning, nil
+	case "info":
+		return SeverityInformation, nil
+	case "hint":
+		return SeverityHint, nil
+	case "off":
+		return SeverityOff, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q, expected error, warn, info, hint or off", s)
+	}
+}
+
+// SetSeverity overrides the severity reported for code.
+func (l *Lint) SetSeverity(code string, s Severity) {
+	l.mu.Lock()
+	l.overrides[code] = s
+	l.mu.Unlock()
+}
+
+// SetIgnores replaces the inline suppressions of filename.
+func (l *Lint) SetIgnores(filename string, ignores []Ignore) {
+	l.mu.Lock()
+	if len(ignores) == 0 {
+		delete(l.ignores, filename)
+	} else {
+		l.ignores[filename] = ignores
+	}
+	l.mu.Unlock()
+}
+
+// Apply returns diags with suppressed and disabled diagnostics removed and
+// severity overrides applied. Errors are passed through unchanged.
+func (l *Lint) Apply(diags []Diagnostic) Diagnostics {
+	out := make(Diagnostics, 0, len(diags))
+	if l == nil {
+		return append(out, diags...)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+next:
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			out = append(out, d)
+			continue
+		}
+		if s, ok := l.overrides[d.Code]; ok && d.Code != "" {
+			if s == SeverityOff {
+				continue
+			}
+			d.Severity = s
+		}
+		for _, ig := range l.ignores[d.Filename] {
+			if ig.matches(d) {
+				continue next
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
@@ -11,6 +11,7 @@ type Reporter struct {
 	mu       sync.Mutex
 	list     []Diagnostic
 	filename string
+	lint     *Lint
 }
 
 func NewReporter() *Reporter {
@@ -25,6 +26,14 @@ func (r *Reporter) SetFilename(fn string) {
 	r.mu.Unlock()
 }
 
+// SetLint makes the reporter apply lint to everything it returns, including
+// diagnostics added before the call.
+func (r *Reporter) SetLint(l *Lint) {
+	r.mu.Lock()
+	r.lint = l
+	r.mu.Unlock()
+}
+
 func (r *Reporter) Add(d Diagnostic) {
 	r.mu.Lock()
 	r.list = append(r.list, d)
@@ -56,15 +65,16 @@ func (r *Reporter) All() Diagnostics {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	// return a copy to avoid races
-	out := make([]Diagnostic, len(r.list))
-	copy(out, r.list)
-	return out
+	return r.lint.Apply(r.list)
 }
 
+// HasErrors reports whether an error was added or lint raised a diagnostic
+// to error. Lint never hides errors, so it can make the build stop but never
+// let it continue.
 func (r *Reporter) HasErrors() bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	for _, d := range r.list {
+	for _, d := range r.lint.Apply(r.list) {
 		if d.Severity == SeverityError {
 			return true
 		}
@@ -73,9 +83,7 @@ func (r *Reporter) HasErrors() bool {
 }
 
 func (r *Reporter) HasWarnings() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	for _, d := range r.list {
+	for _, d := range r.All() {
 		if d.Severity == SeverityWarning {
 			return true
 		}
@@ -83,6 +91,15 @@ func (r *Reporter) HasWarnings() bool {
 	return false
 }
 
+// Relate adds related locations to the diagnostic reported last.
+func (r *Reporter) Relate(related ...Related) {
+	r.mu.Lock()
+	if n := len(r.list); n > 0 {
+		r.list[n-1].Related = append(r.list[n-1].Related, related...)
+	}
+	r.mu.Unlock()
+}
+
 func (r *Reporter) Error(message string, rng Range, code string, hclPath string) {
 	r.Add(Diagnostic{
 		Range:    rng,
@@ -0,0 +1,88 @@
+package engines
+
+import (
+	"fmt"
+	"sync"
+
+	nodets "github.com/kwizyHQ/irex/internal/engines/node-ts"
+	"github.com/kwizyHQ/irex/internal/plan"
+	"github.com/kwizyHQ/irex/internal/plan/steps"
+)
+
+// RenderPlans maps runtime names to the plan that renders their templates
+// into ctx.RenderSession without writing anything to disk.
+var RenderPlans = map[string]func(ctx *plan.PlanContext) *plan.Plan{
+	"node-ts": nodets.NodeTSRenderPlan,
+}
+
+// renderMu serialises renders: template compilation extracts into the
+// shared temp dir, whoever renders (the LSP, the SDK or both at once).
+var renderMu sync.Mutex
+
+// Render runs the render plan of ctx.IR's runtime. It is safe for concurrent
+// use; renders run one at a time.
+func Render(ctx *plan.PlanContext) error {
+	runtime := ctx.IR.Config.Runtime.Name
+	planFunc, ok := RenderPlans[runtime]
+	if !ok {
+		return fmt.Errorf("no render plan for runtime %q", runtime)
+	}
+	renderMu.Lock()
+	defer renderMu.Unlock()
+	return planFunc(ctx).Execute(ctx)
+}
+
+// OutputPaths compiles the templateType templates of ctx.IR's runtime and
+// evaluates their output patterns for every item, without rendering any
+// file. Paths are keyed by item name and relative to the output directory.
+func OutputPaths(ctx *plan.PlanContext, templateType plan.TemplateType) (map[string][]string, error) {
+	runtime := ctx.IR.Config.Runtime.Name
+	planFunc, ok := RenderPlans[runtime]
+	if !ok {
+		return nil, fmt.Errorf("no render plan for runtime %q", runtime)
+	}
+	renderMu.Lock()
+	defer renderMu.Unlock()
+
+	out := map[string][]string{}
+	var walk func(p *plan.Plan) error
+	walk = func(p *plan.Plan) error {
+		for _, step := range p.Steps {
+			switch step := step.(type) {
+			case *plan.PlanStep:
+				if err := walk(step.Plan); err != nil {
+					return err
+				}
+			case *steps.PlanSelectorStep:
+				if selected := step.Select(ctx); selected != nil {
+					if err := walk(selected); err != nil {
+						return err
+					}
+				}
+			case *steps.CompileTemplatesStep:
+				if step.FrameworkType != templateType {
+					continue
+				}
+				if err := step.Run(ctx); err != nil {
+					return err
+				}
+			case *steps.RenderTemplatesStep:
+				if step.TemplateType != templateType {
+					continue
+				}
+				paths, err := step.OutputPaths(ctx)
+				if err != nil {
+					return err
+				}
+				for item, p := range paths {
+					out[item] = append(out[item], p...)
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(planFunc(ctx)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -64,7 +64,7 @@ func NodeTsScaffold(ctx *plan.PlanContext) *plan.Plan {
 			&steps.CommandStep{
 				DescriptionOverride: "Install dependencies",
 				Args: []string{"npm", "install", "--save",
-					"dotenv", "axios", "pino", "fastify", "mongoose",
+					"dotenv", "axios", "pino", "fastify", "mongoose", "zod",
 				},
 			},
 			&steps.CreateFoldersStep{
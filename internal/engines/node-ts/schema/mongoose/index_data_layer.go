@@ -1,6 +1,11 @@
 package mongoose
 
-import "github.com/kwizyHQ/irex/internal/ir"
+import (
+	"encoding/json"
+
+	"github.com/kwizyHQ/irex/internal/engines/node-ts/validation"
+	"github.com/kwizyHQ/irex/internal/ir"
+)
 
 type IndexDataLayer struct {
 	Models       []string
@@ -10,11 +15,30 @@ type IndexDataLayer struct {
 
 func BuildIndexDataLayer(ir *ir.IRBundle) *IndexDataLayer {
 	dl := &IndexDataLayer{
-		URI:          "process.env." + ir.Config.Runtime.Schema.Database.URI,
-		DatabaseName: "process.env." + ir.Config.Runtime.Schema.Database.DB,
+		URI:          tsValue(ir.Config.Runtime.Schema.Database.URI),
+		DatabaseName: tsValue(ir.Config.Runtime.Schema.Database.DB),
 	}
 	for _, m := range ir.Models {
-		dl.Models = append(dl.Models, m.Name)
+		dl.Models = append(dl.Models, validation.Ident(m.Name))
 	}
 	return dl
 }
+
+// tsValue renders v as a TypeScript expression: process.env.NAME (falling
+// back to its default) for runtime values, secrets included, and a string
+// literal otherwise.
+func tsValue(v ir.IRValue) string {
+	if !v.IsRuntime() {
+		return tsString(v.Literal)
+	}
+	expr := "process.env." + v.Env
+	if v.HasDefault {
+		expr = "(" + expr + " ?? " + tsString(v.Default) + ")"
+	}
+	return expr
+}
+
+func tsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -1,12 +1,16 @@
 package mongoose
 
 import (
+	"github.com/kwizyHQ/irex/internal/engines/node-ts/validation"
 	"github.com/kwizyHQ/irex/internal/ir"
 	"github.com/zclconf/go-cty/cty"
 )
 
 type MongoModel struct {
-	Name        string
+	// Name is the IR model name, which the model's files are rendered for.
+	Name string
+	// Ident is the model's TypeScript identifier (auth.User is AuthUser).
+	Ident       string
 	Fields      []MongoField
 	Indexes     []MongoIndex
 	Config      MongoModelConfig
@@ -73,7 +77,8 @@ type MongoRelation struct {
 
 func BuildMongoModel(m ir.IRModel) MongoModel {
 	model := MongoModel{
-		Name: m.Name,
+		Name:  m.Name,
+		Ident: validation.Ident(m.Name),
 		// Description: m.Config.Description,
 	}
 
@@ -114,21 +119,21 @@ func BuildMongoModel(m ir.IRModel) MongoModel {
 		for _, r := range m.Relations.HasMany {
 			model.Relations = append(model.Relations, MongoRelation{
 				Name: r.Name,
-				Ref:  r.Ref,
+				Ref:  validation.Ident(r.Ref),
 				Type: "hasMany",
 			})
 		}
 		for _, r := range m.Relations.BelongsTo {
 			model.Relations = append(model.Relations, MongoRelation{
 				Name: r.Name,
-				Ref:  r.Ref,
+				Ref:  validation.Ident(r.Ref),
 				Type: "belongsTo",
 			})
 		}
 		for _, r := range m.Relations.ManyToMany {
 			model.Relations = append(model.Relations, MongoRelation{
 				Name: r.Name,
-				Ref:  r.Ref,
+				Ref:  validation.Ident(r.Ref),
 				Type: "manyToMany",
 			})
 		}
@@ -1,7 +1,12 @@
 import { DataLayer, Filter, FindOptions } from "./dl.types";
 {{ range .Models -}}
-import {{ title .}}Model, { {{ title .}}Schema } from "./{{ lower . }}"
+import {{ . }}Model, { {{ . }}Schema } from "./{{ lower . }}"
 {{ end }}
+export const connection = {
+  uri: {{ .URI }},
+  dbName: {{ .DatabaseName }},
+};
+
 export function mongooseAdapter<T>(model: any): DataLayer<T> {
   return {
     async create(data) {
@@ -52,7 +57,7 @@ export function mongooseAdapter<T>(model: any): DataLayer<T> {
 
 const DL = {
 {{ range .Models -}}
-    {{ title . }}Model : mongooseAdapter<typeof {{ title .}}Schema>({{ title . }}Model),
+    {{ . }}Model : mongooseAdapter<typeof {{ . }}Schema>({{ . }}Model),
 {{ end }}
 }
 
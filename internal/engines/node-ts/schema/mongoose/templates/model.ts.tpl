@@ -1,6 +1,6 @@
 import mongoose from "mongoose";
 
-export const {{ .Name }}Schema = new mongoose.Schema({
+export const {{ .Ident }}Schema = new mongoose.Schema({
 {{- range .Fields }}
   {{ .Name }}: {
     {{- if .IsArray }}
@@ -33,11 +33,11 @@ export const {{ .Name }}Schema = new mongoose.Schema({
 });
 
 {{- range .Indexes }}
-{{ $.Name }}Schema.index(
+{{ $.Ident }}Schema.index(
   { {{ range $i, $f := .Fields }}{{ if $i }}, {{ end }}{{ $f }}: 1{{ end }} },
   { unique: {{ .Unique }} }
 );
 {{- end }}
 
-export default mongoose.model("{{ .Name }}", {{ .Name }}Schema);
+export default mongoose.model("{{ .Ident }}", {{ .Ident }}Schema);
 
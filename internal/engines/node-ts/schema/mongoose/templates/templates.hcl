@@ -12,6 +12,24 @@ template "index.ts.tpl" {
 
 template "model.ts.tpl" {
   data   = "schema:model"
-  output = "models/{{ lower .Name }}.ts" // with respect to generated folder defined in irex.hcl
+  output = "models/{{ lower .Ident }}.ts" // with respect to generated folder defined in irex.hcl
   mode   = "per-item"
 }
+
+template "validators.schema.ts.tpl" {
+  data   = "schema:validators"
+  output = "validators/{{ lower .Ident }}.schema.ts"
+  mode   = "per-item"
+}
+
+template "validators.zod.ts.tpl" {
+  data   = "schema:validators"
+  output = "validators/{{ lower .Ident }}.zod.ts"
+  mode   = "per-item"
+}
+
+template "validators.index.ts.tpl" {
+  data   = "schema:index"
+  output = "validators/index.ts"
+  mode   = "single"
+}
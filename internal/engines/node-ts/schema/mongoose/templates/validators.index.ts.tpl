@@ -0,0 +1,4 @@
+{{ range .Models -}}
+export * from "./{{ lower . }}.schema";
+export * from "./{{ lower . }}.zod";
+{{ end -}}
@@ -0,0 +1,8 @@
+// JSON Schemas of {{ .Ident }}, which the routes validate requests and
+// responses with.
+
+export const {{ .Ident }}CreateJSONSchema = {{ .Create }} as const
+
+export const {{ .Ident }}UpdateJSONSchema = {{ .Update }} as const
+
+export const {{ .Ident }}JSONSchema = {{ .Response }} as const
@@ -0,0 +1,12 @@
+// Zod schemas of {{ .Ident }}, matching its JSON Schemas. They only depend on
+// zod, so frontends can share them.
+import { z } from "zod";
+
+export const {{ .Ident }}CreateZod = {{ .ZodCreate }};
+export type {{ .Ident }}Create = z.infer<typeof {{ .Ident }}CreateZod>;
+
+export const {{ .Ident }}UpdateZod = {{ .ZodUpdate }};
+export type {{ .Ident }}Update = z.infer<typeof {{ .Ident }}UpdateZod>;
+
+export const {{ .Ident }}Zod = {{ .ZodResponse }};
+export type {{ .Ident }} = z.infer<typeof {{ .Ident }}Zod>;
@@ -4,6 +4,7 @@ import (
 	"embed"
 	"io/fs"
 
+	"github.com/kwizyHQ/irex/internal/engines/node-ts/validation"
 	"github.com/kwizyHQ/irex/internal/plan"
 	steps "github.com/kwizyHQ/irex/internal/plan/steps"
 )
@@ -17,9 +18,9 @@ func (p *IndexDataProvider) DataKey() string {
 	return "schema:index"
 }
 
-func (p *IndexDataProvider) Resolve(ctx *plan.PlanContext) (any, steps.Cardinality) {
+func (p *IndexDataProvider) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
 	indexData := BuildIndexDataLayer(ctx.IR)
-	return indexData, steps.Single
+	return indexData, plan.Single
 }
 
 type ModelDataProvider struct{}
@@ -27,14 +28,28 @@ type ModelDataProvider struct{}
 func (p *ModelDataProvider) DataKey() string {
 	return "schema:model"
 }
-func (p *ModelDataProvider) Resolve(ctx *plan.PlanContext) (any, steps.Cardinality) {
+func (p *ModelDataProvider) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
 	// get model values from Models
 	models := make([]any, 0)
 
 	for _, m := range ctx.IR.Models {
 		models = append(models, BuildMongoModel(m))
 	}
-	return models, steps.Many
+	return models, plan.Many
+}
+
+type ValidatorsDataProvider struct{}
+
+func (p *ValidatorsDataProvider) DataKey() string {
+	return "schema:validators"
+}
+
+func (p *ValidatorsDataProvider) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
+	validators := make([]any, 0)
+	for _, m := range ctx.IR.Models {
+		validators = append(validators, validation.Build(m))
+	}
+	return validators, plan.Many
 }
 
 func MongooseTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
@@ -51,9 +66,10 @@ func MongooseTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
 			},
 			&steps.RenderTemplatesStep{
 				TemplateType: plan.TemplateTypeSchema,
-				Providers: []steps.DataProvider{
+				Providers: []plan.DataProvider{
 					&IndexDataProvider{},
 					&ModelDataProvider{},
+					&ValidatorsDataProvider{},
 				},
 			},
 		},
@@ -0,0 +1,113 @@
+package fastify
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/engines/node-ts/validation"
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/kwizyHQ/irex/internal/jsonschema"
+)
+
+// RouteDataLayer is one routes file: the routes of a service, or of the
+// operations declared directly under services when Name is "global".
+type RouteDataLayer struct {
+	Name string
+	// Model is the TypeScript identifier of the service's model, empty for
+	// services without one.
+	Model  string
+	Routes []Route
+}
+
+type Route struct {
+	Method    string
+	URL       string // fastify form, with the http base path
+	Operation string
+	// Action is the data action (create, read, update, delete, list), empty
+	// for custom operations.
+	Action string
+	// Query is the JSON Schema of a list's query string, empty when it
+	// takes none.
+	Query string
+}
+
+type RoutesIndexDataLayer struct {
+	Items []RouteDataLayer
+}
+
+// BuildRouteDataLayers groups the exposed routes of irb by service, in path
+// order.
+func BuildRouteDataLayers(irb *ir.IRBundle) []RouteDataLayer {
+	ids := make([]string, 0, len(irb.Routes))
+	for id := range irb.Routes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	base := strings.TrimRight(irb.Http.BasePath, "/")
+	var files []RouteDataLayer
+	index := map[string]int{}
+	for _, id := range ids {
+		route := irb.Routes[id]
+		svc, hasSvc := irb.Services[route.Service]
+		if hasSvc && svc.Expose != nil && !*svc.Expose {
+			continue
+		}
+		name := route.Service
+		if name == "" {
+			name = "global"
+		}
+		i, ok := index[name]
+		if !ok {
+			file := RouteDataLayer{Name: name}
+			if _, ok := irb.Models[svc.Model]; hasSvc && ok {
+				file.Model = validation.Ident(svc.Model)
+			}
+			i = len(files)
+			index[name] = i
+			files = append(files, file)
+		}
+
+		r := Route{
+			Method:    route.Method,
+			URL:       base + fastifyPath(route.Segments),
+			Operation: route.Operation,
+		}
+		if op := irb.Operations[route.Operation]; op.Kind == ir.OperationKindData && op.Data != nil && files[i].Model != "" {
+			r.Action = string(op.Data.Action)
+			if op.Data.Action == ir.DataList && len(jsonschema.QueryOrder(*op.Data)) > 0 {
+				query, _ := json.MarshalIndent(jsonschema.ListQuery(irb.Models[svc.Model], *op.Data), "      ", "  ")
+				r.Query = string(query)
+			}
+		}
+		files[i].Routes = append(files[i].Routes, r)
+	}
+	return files
+}
+
+// fastifyPath writes segments the way fastify declares them: /users/:id,
+// :id(^\d+$) for patterns and * for the rest of the path.
+func fastifyPath(segments []ir.PathSegment) string {
+	var sb strings.Builder
+	for _, seg := range segments {
+		sb.WriteByte('/')
+		switch seg.Kind {
+		case ir.SegmentStatic:
+			sb.WriteString(seg.Literal)
+		case ir.SegmentWildcard, ir.SegmentCatchAll:
+			sb.WriteByte('*')
+		case ir.SegmentOptional:
+			sb.WriteString(":" + seg.Name + "?")
+		default:
+			sb.WriteString(":" + seg.Name)
+			if seg.Regex != "" {
+				sb.WriteString("(" + seg.Regex + ")")
+			}
+		}
+	}
+	if sb.Len() == 0 {
+		return "/"
+	}
+	return sb.String()
+}
@@ -1,10 +1,34 @@
 import { FastifyPluginAsync } from 'fastify'
+{{- if .Model }}
+import { {{ .Model }}JSONSchema, {{ .Model }}CreateJSONSchema, {{ .Model }}UpdateJSONSchema } from '../validators/{{ lower .Model }}.schema'
+{{- end }}
 
 const plugin: FastifyPluginAsync = async (fastify) => {
-  // Route: {{ .Name }}
-  fastify.get('/{{ lower .Name }}', async (request, reply) => {
-    return { message: 'GET {{ .Name }}' }
+{{- range .Routes }}
+
+  // {{ .Operation }}
+  fastify.route({
+    method: '{{ .Method }}',
+    url: '{{ .URL }}',
+{{- if eq .Action "create" }}
+    schema: { body: {{ $.Model }}CreateJSONSchema, response: { 201: {{ $.Model }}JSONSchema } },
+{{- else if eq .Action "read" }}
+    schema: { response: { 200: {{ $.Model }}JSONSchema } },
+{{- else if eq .Action "update" }}
+    schema: { body: {{ $.Model }}UpdateJSONSchema },
+{{- else if eq .Action "list" }}
+    schema: {
+      {{- if .Query }}
+      querystring: {{ .Query }},
+      {{- end }}
+      response: { 200: { type: 'array', items: {{ $.Model }}JSONSchema } },
+    },
+{{- end }}
+    handler: async (request, reply) => {
+      return reply.code(501).send({ statusCode: 501, error: 'Not Implemented', message: '{{ .Operation }} is not implemented' })
+    },
   })
+{{- end }}
 }
 
 export default plugin
@@ -17,9 +17,33 @@ func (p *AppDataProvider) DataKey() string {
 	return "service:app"
 }
 
-func (p *AppDataProvider) Resolve(ctx *plan.PlanContext) (any, steps.Cardinality) {
+func (p *AppDataProvider) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
 	appData := BuildAppDataLayer(ctx.IR)
-	return appData, steps.Single
+	return appData, plan.Single
+}
+
+type RoutesDataProvider struct{}
+
+func (p *RoutesDataProvider) DataKey() string {
+	return "service:routes"
+}
+
+func (p *RoutesDataProvider) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
+	routes := make([]any, 0)
+	for _, r := range BuildRouteDataLayers(ctx.IR) {
+		routes = append(routes, r)
+	}
+	return routes, plan.Many
+}
+
+type RoutesIndexDataProvider struct{}
+
+func (p *RoutesIndexDataProvider) DataKey() string {
+	return "service:routes_index"
+}
+
+func (p *RoutesIndexDataProvider) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
+	return &RoutesIndexDataLayer{Items: BuildRouteDataLayers(ctx.IR)}, plan.Single
 }
 
 func FastifyTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
@@ -36,8 +60,10 @@ func FastifyTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
 			},
 			&steps.RenderTemplatesStep{
 				TemplateType: plan.TemplateTypeService,
-				Providers: []steps.DataProvider{
+				Providers: []plan.DataProvider{
 					&AppDataProvider{},
+					&RoutesDataProvider{},
+					&RoutesIndexDataProvider{},
 				},
 			},
 		},
@@ -0,0 +1,195 @@
+// Package validation builds the JSON Schema and Zod validators of models
+// for the node-ts templates: the routes validate requests and responses
+// with the JSON Schemas, and the Zod schemas are shared with frontends.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/kwizyHQ/irex/internal/jsonschema"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Validators are the validators of one model, as TypeScript expressions.
+type Validators struct {
+	// Name is the IR model name, which the validator files are rendered for.
+	Name string
+	// Ident is the model's TypeScript identifier (auth.User is AuthUser).
+	Ident string
+	// Create, Update and Response are JSON Schemas. Update requires
+	// nothing, and Response leaves private fields out.
+	Create   string
+	Update   string
+	Response string
+	// ZodCreate, ZodUpdate and ZodResponse are the matching z.object()s.
+	ZodCreate   string
+	ZodUpdate   string
+	ZodResponse string
+}
+
+// Build returns the validators of m.
+func Build(m ir.IRModel) Validators {
+	return Validators{
+		Name:        m.Name,
+		Ident:       Ident(m.Name),
+		Create:      jsonText(jsonschema.Model(m, jsonschema.Create)),
+		Update:      jsonText(jsonschema.Model(m, jsonschema.Update)),
+		Response:    jsonText(jsonschema.Model(m, jsonschema.Response)),
+		ZodCreate:   zodModel(m, jsonschema.Create),
+		ZodUpdate:   zodModel(m, jsonschema.Update),
+		ZodResponse: zodModel(m, jsonschema.Response),
+	}
+}
+
+// Ident turns a model name into a TypeScript identifier; models of spec
+// modules are namespaced (auth.User) and become AuthUser.
+func Ident(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func jsonText(s *jsonschema.Schema) string {
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return string(data)
+}
+
+// zodModel mirrors jsonschema.Model; responses gain the same generated
+// properties.
+func zodModel(m ir.IRModel, mode jsonschema.Mode) string {
+	var extra []string
+	if mode == jsonschema.Response {
+		for _, p := range jsonschema.Generated(m) {
+			expr := "z.string()"
+			if p.Schema.Format == "date-time" {
+				expr = "z.coerce.date()"
+			}
+			extra = append(extra, tsKey(p.Name)+": "+expr)
+		}
+	}
+	return zodObject(m.Fields, mode, "", extra)
+}
+
+// zodObject mirrors jsonschema.Fields: the same fields, required and
+// constrained the same way.
+func zodObject(fields []ir.IRModelField, mode jsonschema.Mode, indent string, extra []string) string {
+	var b strings.Builder
+	b.WriteString("z.object({\n")
+	for _, f := range fields {
+		switch f.Visibility {
+		case "internal":
+			continue
+		case "private":
+			if mode == jsonschema.Response {
+				continue
+			}
+		}
+		fmt.Fprintf(&b, "%s  %s: %s,\n", indent, tsKey(f.Name), zodField(f, mode, indent+"  "))
+	}
+	for _, e := range extra {
+		fmt.Fprintf(&b, "%s  %s,\n", indent, e)
+	}
+	b.WriteString(indent + "})")
+	return b.String()
+}
+
+func zodField(f ir.IRModelField, mode jsonschema.Mode, indent string) string {
+	var expr string
+	if len(f.Fields) > 0 {
+		expr = zodObject(f.Fields, mode, indent, nil)
+	} else {
+		expr = zodType(f)
+	}
+	if f.Description != "" {
+		expr += ".describe(" + tsString(f.Description) + ")"
+	}
+	hasDefault := mode != jsonschema.Update && !f.Default.IsNull() && f.Default.IsWhollyKnown()
+	if hasDefault {
+		if raw, err := ctyjson.Marshal(f.Default, f.Default.Type()); err == nil {
+			return expr + ".default(" + string(raw) + ")"
+		}
+	}
+	if !f.Required || mode == jsonschema.Update {
+		expr += ".optional()"
+	}
+	return expr
+}
+
+func zodType(f ir.IRModelField) string {
+	switch f.Type {
+	case "string", "enum":
+		return zodString(f)
+	case "int", "integer":
+		return "z.number().int()" + zodBounds(f)
+	case "float", "number":
+		return "z.number()" + zodBounds(f)
+	case "bool", "boolean":
+		return "z.boolean()"
+	case "date":
+		return "z.coerce.date()"
+	case "objectId":
+		return "z.string().regex(/^[0-9a-fA-F]{24}$/)"
+	case "string[]":
+		return "z.array(" + zodString(f) + ")"
+	case "array":
+		return "z.array(z.unknown())"
+	case "object":
+		return "z.record(z.string(), z.unknown())"
+	}
+	return "z.unknown()"
+}
+
+func zodString(f ir.IRModelField) string {
+	expr := "z.string()"
+	if f.Trim {
+		expr += ".trim()"
+	}
+	if f.MinLength != nil {
+		expr += fmt.Sprintf(".min(%d)", *f.MinLength)
+	}
+	if f.MaxLength != nil {
+		expr += fmt.Sprintf(".max(%d)", *f.MaxLength)
+	}
+	if f.Match != "" {
+		expr += ".regex(new RegExp(" + tsString(f.Match) + ")"
+		if f.Message != "" {
+			expr += ", " + tsString(f.Message)
+		}
+		expr += ")"
+	}
+	return expr
+}
+
+func zodBounds(f ir.IRModelField) string {
+	var expr string
+	if f.Min != nil {
+		expr += fmt.Sprintf(".min(%d)", *f.Min)
+	}
+	if f.Max != nil {
+		expr += fmt.Sprintf(".max(%d)", *f.Max)
+	}
+	return expr
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsKey quotes an object key that is not a plain identifier.
+func tsKey(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return tsString(name)
+}
+
+func tsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
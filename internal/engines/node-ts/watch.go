@@ -7,10 +7,12 @@ import (
 	"github.com/kwizyHQ/irex/internal/plan/steps"
 )
 
-func NodeTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
+// NodeTSRenderPlan compiles and renders the schema and service templates
+// into ctx.RenderSession without writing anything to disk.
+func NodeTSRenderPlan(ctx *plan.PlanContext) *plan.Plan {
 	return &plan.Plan{
-		Name: "Node TypeScript Watch",
-		ID:   "watch-node-ts",
+		Name: "Node TypeScript Render",
+		ID:   "render-node-ts",
 		Steps: []plan.Step{
 			// let's select the schema framework here
 			&steps.PlanSelectorStep{
@@ -26,8 +28,18 @@ func NodeTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
 				},
 				Key: ctx.IR.Config.Runtime.Service.Framework,
 			},
+		},
+	}
+}
+
+func NodeTSWatchPlan(ctx *plan.PlanContext) *plan.Plan {
+	return &plan.Plan{
+		Name: "Node TypeScript Watch",
+		ID:   "watch-node-ts",
+		Steps: []plan.Step{
+			&plan.PlanStep{Plan: NodeTSRenderPlan(ctx)},
 			&steps.FlushRendersStep{
-				DestDir: ".",
+				DestDir: ctx.IR.Config.Paths.Root,
 			},
 			&steps.WatchCommandStep{
 				IDValue: "npm-dev-node-ts",
@@ -0,0 +1,264 @@
+// Package client generates a typed TypeScript client of a project's API:
+// one method per exposed operation, grouped by service, with the model
+// types of the bodies and responses and typed error results.
+package client
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/gobuffalo/flect"
+	"github.com/kwizyHQ/irex/internal/engines/node-ts/validation"
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/kwizyHQ/irex/internal/jsonschema"
+)
+
+//go:embed client.ts.tpl
+var clientTemplate string
+
+var tmpl = template.Must(template.New("client").Parse(clientTemplate))
+
+// data is what client.ts.tpl renders.
+type data struct {
+	Project string
+	Version string
+	Models  []model
+	Queries []query
+	Groups  []group
+	Methods []method // operations declared directly under services
+}
+
+// model holds the TypeScript types of one model: as returned, and as the
+// create and update bodies.
+type model struct {
+	Name     string
+	Response string
+	Create   string
+	Update   string
+}
+
+// query is the type of a list operation's query string.
+type query struct {
+	Name string
+	Type string
+}
+
+type group struct {
+	Name    string
+	Methods []method
+}
+
+type method struct {
+	Name        string
+	Operation   string
+	Description string
+	Params      string // the method's parameters
+	Result      string // the type of the response body
+	Errors      string // the route's rate limit and policy errors, or never
+	Call        string // the request, as a TypeScript object
+}
+
+// Generate returns the TypeScript client of b's exposed routes.
+func Generate(b *ir.IRBundle) ([]byte, error) {
+	d := data{Project: b.Config.Project.Name, Version: b.Config.Project.Version}
+
+	names := make([]string, 0, len(b.Models))
+	for name := range b.Models {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m := b.Models[name]
+		d.Models = append(d.Models, model{
+			Name:     validation.Ident(name),
+			Response: tsType(jsonschema.Model(m, jsonschema.Response), ""),
+			Create:   tsType(jsonschema.Model(m, jsonschema.Create), ""),
+			Update:   tsType(jsonschema.Model(m, jsonschema.Update), ""),
+		})
+	}
+
+	ids := make([]string, 0, len(b.Routes))
+	for id := range b.Routes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	groups := map[string]int{}
+	for _, id := range ids {
+		route := b.Routes[id]
+		svc, ok := b.Services[route.Service]
+		if ok && svc.Expose != nil && !*svc.Expose {
+			continue
+		}
+		m := buildMethod(b, &d, route)
+		if route.Service == "" {
+			d.Methods = append(d.Methods, m)
+			continue
+		}
+		i, ok := groups[route.Service]
+		if !ok {
+			i = len(d.Groups)
+			groups[route.Service] = i
+			d.Groups = append(d.Groups, group{Name: flect.Camelize(route.Service)})
+		}
+		d.Groups[i].Methods = append(d.Groups[i].Methods, m)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildMethod describes the client method of route. Data operations on a
+// model take and return its types; other operations take an optional body
+// and return unknown.
+func buildMethod(b *ir.IRBundle, d *data, route ir.IRRoute) method {
+	op := b.Operations[route.Operation]
+	m := method{
+		Name:        flect.Camelize(strings.TrimPrefix(route.Operation, route.Service+".")),
+		Operation:   route.Operation,
+		Description: strings.ReplaceAll(op.Description, "*/", "*\\/"),
+		Result:      "unknown",
+	}
+	path, params := pathExpr(b.Http.BasePath, route.Segments)
+	call := []string{"method: " + tsString(route.Method), "path: " + path}
+	var args []string
+	if params != "" {
+		args = append(args, "params: "+params)
+	}
+
+	modelName := ""
+	if svc, ok := b.Services[route.Service]; ok {
+		if _, ok := b.Models[svc.Model]; ok {
+			modelName = svc.Model
+		}
+	}
+	if data := op.Data; op.Kind == ir.OperationKindData && data != nil && modelName != "" {
+		t := validation.Ident(modelName)
+		switch data.Action {
+		case ir.DataCreate:
+			args = append(args, "body: "+t+"Create")
+			call = append(call, "body")
+		case ir.DataUpdate:
+			args = append(args, "body: "+t+"Update")
+			call = append(call, "body")
+		}
+		switch {
+		case data.ReturnsList:
+			m.Result = t + "[]"
+		case data.ReturnsEntity:
+			m.Result = t
+		default:
+			m.Result = "void"
+		}
+		if data.Action == ir.DataList && len(jsonschema.QueryOrder(*data)) > 0 {
+			q := query{Name: flect.Pascalize(route.Service) + flect.Pascalize(m.Name) + "Query", Type: queryType(b.Models[modelName], *data)}
+			d.Queries = append(d.Queries, q)
+			args = append(args, "query: "+q.Name+" = {}")
+			call = append(call, "query")
+		}
+	} else if route.Method == "POST" || route.Method == "PUT" || route.Method == "PATCH" {
+		args = append(args, "body?: unknown")
+		call = append(call, "body")
+	}
+
+	var errs []string
+	limits := map[int][]string{}
+	for _, name := range route.RateLimits() {
+		if rl, ok := b.RateLimits[name]; ok {
+			limits[rl.StatusCode()] = append(limits[rl.StatusCode()], name)
+		}
+	}
+	if len(limits) > 0 {
+		var statuses, names []string
+		for _, status := range sortedKeys(limits) {
+			statuses = append(statuses, fmt.Sprintf("%d: %s", status, tsStrings(limits[status])))
+			names = append(names, limits[status]...)
+		}
+		call = append(call, "rateLimits: { "+strings.Join(statuses, ", ")+" }")
+		errs = append(errs, "RateLimitError<"+tsUnion(names)+">")
+	}
+	if policies := append(append([]string{}, route.RequestPolicies...), route.ResourcePolicies...); len(policies) > 0 {
+		call = append(call, "policies: "+tsStrings(policies))
+		errs = append(errs, "PolicyError<"+tsUnion(policies)+">")
+	}
+	m.Errors = "never"
+	if len(errs) > 0 {
+		m.Errors = strings.Join(errs, " | ")
+	}
+	m.Params = strings.Join(args, ", ")
+	m.Call = "{ " + strings.Join(call, ", ") + " }"
+	return m
+}
+
+// pathExpr returns route's path as a TypeScript template literal reading
+// the path parameters, and the type of those parameters, empty when it has
+// none. A catch-all is read from params.wildcard.
+func pathExpr(base string, segments []ir.PathSegment) (string, string) {
+	var path strings.Builder
+	var fields []string
+	path.WriteString("`" + strings.TrimRight(base, "/"))
+	for _, seg := range segments {
+		switch seg.Kind {
+		case ir.SegmentStatic:
+			path.WriteString("/" + seg.Literal)
+		case ir.SegmentWildcard, ir.SegmentCatchAll:
+			path.WriteString("/${params.wildcard}")
+			fields = append(fields, "wildcard: string")
+		case ir.SegmentOptional:
+			ref := "params" + tsAccess(seg.Name)
+			path.WriteString(`${` + ref + ` === undefined ? "" : "/" + encodeURIComponent(` + ref + `)}`)
+			fields = append(fields, tsKey(seg.Name)+"?: string")
+		default:
+			path.WriteString("/${encodeURIComponent(params" + tsAccess(seg.Name) + ")}")
+			fields = append(fields, tsKey(seg.Name)+": string")
+		}
+	}
+	if len(segments) == 0 {
+		path.WriteString("/")
+	}
+	path.WriteString("`")
+	if len(fields) == 0 {
+		return path.String(), ""
+	}
+	return path.String(), "{ " + strings.Join(fields, "; ") + " }"
+}
+
+func queryType(m ir.IRModel, data ir.DataOperationMeta) string {
+	s := jsonschema.ListQuery(m, data)
+	s.Required = nil
+	return tsType(s, "")
+}
+
+func sortedKeys(m map[int][]string) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func tsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func tsStrings(ss []string) string {
+	b, _ := json.Marshal(ss)
+	return string(b)
+}
+
+func tsUnion(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = tsString(s)
+	}
+	return strings.Join(quoted, " | ")
+}
@@ -0,0 +1,177 @@
+// Code generated by irex export client. DO NOT EDIT.
+// Client of {{ .Project }}{{ if .Version }} {{ .Version }}{{ end }}.
+
+// ─────────────────────────────────────────────
+// Models
+// ─────────────────────────────────────────────
+{{ range .Models }}
+export type {{ .Name }} = {{ .Response }};
+
+export type {{ .Name }}Create = {{ .Create }};
+
+export type {{ .Name }}Update = {{ .Update }};
+{{ end }}
+{{- range .Queries }}
+export type {{ .Name }} = {{ .Type }};
+{{ end }}
+// ─────────────────────────────────────────────
+// Results
+// ─────────────────────────────────────────────
+
+/** The body of the API's error responses. */
+export interface ErrorBody {
+  statusCode?: number;
+  error?: string;
+  message: string;
+}
+
+/** A rate limit of the route refused the request. */
+export interface RateLimitError<Name extends string = string> {
+  kind: "rate_limit";
+  status: number;
+  /** The rate limits of the route that answer with this status. */
+  rateLimits: Name[];
+  /** Seconds to wait before retrying, from Retry-After. */
+  retryAfter?: number;
+  body: unknown;
+}
+
+/** A policy of the route refused the request. */
+export interface PolicyError<Name extends string = string> {
+  kind: "policy";
+  status: 401 | 403;
+  /** The policies of the route. */
+  policies: Name[];
+  body: unknown;
+}
+
+/** The request did not validate. */
+export interface ValidationError {
+  kind: "validation";
+  status: 400;
+  body: ErrorBody;
+}
+
+export interface NotFoundError {
+  kind: "not_found";
+  status: 404;
+  body: ErrorBody;
+}
+
+/** Any other error status. */
+export interface HttpError {
+  kind: "http";
+  status: number;
+  body: unknown;
+}
+
+/** The request did not reach the API. */
+export interface NetworkError {
+  kind: "network";
+  cause: unknown;
+}
+
+export type ClientError = ValidationError | NotFoundError | HttpError | NetworkError;
+
+/** Every method resolves to a Result; it never throws. */
+export type Result<T, E = never> =
+  | { ok: true; status: number; data: T }
+  | { ok: false; error: E | ClientError };
+
+// ─────────────────────────────────────────────
+// Transport
+// ─────────────────────────────────────────────
+
+export interface ClientOptions {
+  /** Prepended to every path, such as https://api.example.com. Paths are relative to the page without it. */
+  baseUrl?: string;
+  /** Headers sent with every request, such as Authorization. */
+  headers?: Record<string, string> | (() => Record<string, string> | Promise<Record<string, string>>);
+  fetch?: typeof fetch;
+}
+
+interface Call {
+  method: string;
+  path: string;
+  query?: object;
+  body?: unknown;
+  /** The route's rate limits by the status they answer with. */
+  rateLimits?: Record<number, string[]>;
+  policies?: string[];
+}
+
+async function send(options: ClientOptions, call: Call): Promise<Result<any, any>> {
+  let url = (options.baseUrl ?? "").replace(/\/$/, "") + call.path;
+  if (call.query) {
+    const search = new URLSearchParams();
+    for (const [key, value] of Object.entries(call.query)) {
+      if (value !== undefined && value !== null) search.append(key, String(value));
+    }
+    const qs = search.toString();
+    if (qs) url += "?" + qs;
+  }
+  const headers: Record<string, string> = {
+    accept: "application/json",
+    ...(typeof options.headers === "function" ? await options.headers() : options.headers),
+  };
+  if (call.body !== undefined) headers["content-type"] = "application/json";
+
+  let res: Response;
+  try {
+    res = await (options.fetch ?? fetch)(url, {
+      method: call.method,
+      headers,
+      body: call.body === undefined ? undefined : JSON.stringify(call.body),
+    });
+  } catch (cause) {
+    return { ok: false, error: { kind: "network", cause } };
+  }
+  const text = await res.text();
+  let body: unknown = text || undefined;
+  try {
+    if (text) body = JSON.parse(text);
+  } catch {
+    // not JSON: keep the text
+  }
+  if (res.ok) return { ok: true, status: res.status, data: body };
+
+  const status = res.status;
+  const limited = call.rateLimits?.[status];
+  if (limited) {
+    const retryAfter = Number(res.headers.get("retry-after") ?? NaN);
+    return {
+      ok: false,
+      error: { kind: "rate_limit", status, rateLimits: limited, retryAfter: isNaN(retryAfter) ? undefined : retryAfter, body },
+    };
+  }
+  if ((status === 401 || status === 403) && call.policies?.length) {
+    return { ok: false, error: { kind: "policy", status, policies: call.policies, body } };
+  }
+  if (status === 400) return { ok: false, error: { kind: "validation", status, body: body as ErrorBody } };
+  if (status === 404) return { ok: false, error: { kind: "not_found", status, body: body as ErrorBody } };
+  return { ok: false, error: { kind: "http", status, body } };
+}
+
+// ─────────────────────────────────────────────
+// Client
+// ─────────────────────────────────────────────
+
+export function createClient(options: ClientOptions = {}) {
+  const call = <T, E>(c: Call) => send(options, c) as Promise<Result<T, E>>;
+  return {
+{{- range .Groups }}
+    {{ .Name }}: {
+{{- range .Methods }}
+      /** {{ .Operation }}{{ if .Description }}: {{ .Description }}{{ end }} */
+      {{ .Name }}: ({{ .Params }}) => call<{{ .Result }}, {{ .Errors }}>({{ .Call }}),
+{{- end }}
+    },
+{{- end }}
+{{- range .Methods }}
+    /** {{ .Operation }}{{ if .Description }}: {{ .Description }}{{ end }} */
+    {{ .Name }}: ({{ .Params }}) => call<{{ .Result }}, {{ .Errors }}>({{ .Call }}),
+{{- end }}
+  };
+}
+
+export type Client = ReturnType<typeof createClient>;
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/jsonschema"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsType writes s as a TypeScript type, objects one property per line at
+// indent. Dates are strings, as they travel in JSON.
+func tsType(s *jsonschema.Schema, indent string) string {
+	if len(s.Enum) > 0 {
+		return tsUnion(s.Enum)
+	}
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if s.Items == nil {
+			return "unknown[]"
+		}
+		item := tsType(s.Items, indent)
+		if strings.Contains(item, "|") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if s.Properties == nil {
+			return "Record<string, unknown>"
+		}
+		return tsObject(s, indent)
+	}
+	return "unknown"
+}
+
+func tsObject(s *jsonschema.Schema, indent string) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		p := s.Properties[name]
+		if p.Description != "" {
+			b.WriteString(indent + "  /** " + strings.ReplaceAll(p.Description, "*/", "*\\/") + " */\n")
+		}
+		optional := "?"
+		if slices.Contains(s.Required, name) {
+			optional = ""
+		}
+		b.WriteString(indent + "  " + tsKey(name) + optional + ": " + tsType(p, indent+"  ") + ";\n")
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// tsKey quotes a property name that is not a plain identifier.
+func tsKey(name string) string {
+	if identifier.MatchString(name) {
+		return name
+	}
+	return tsString(name)
+}
+
+// tsAccess reads the property name of an object: .name or ["na-me"].
+func tsAccess(name string) string {
+	if identifier.MatchString(name) {
+		return "." + name
+	}
+	return "[" + tsString(name) + "]"
+}
@@ -0,0 +1,276 @@
+package openapi
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/kwizyHQ/irex/internal/jsonschema"
+)
+
+const jsonMedia = "application/json"
+
+// errorSchema is the body of the framework's error responses.
+const errorSchema = "Error"
+
+// Build returns the OpenAPI document of b: one path per route, relative to
+// the servers, which carry the http base path, with schemas generated from
+// the models of data services.
+func Build(b *ir.IRBundle) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       b.Config.Project.Name,
+			Version:     b.Config.Project.Version,
+			Description: b.Config.Project.Description,
+		},
+		Servers:    servers(b),
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: map[string]*Schema{errorSchema: errorBody()}},
+		CORS:       cors(b.Http),
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+	if b.Config.Project.License != "" {
+		doc.Info.License = &License{Name: b.Config.Project.License}
+	}
+
+	ids := make([]string, 0, len(b.Routes))
+	for id := range b.Routes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var tags []string
+	for _, id := range ids {
+		route := b.Routes[id]
+		if svc, ok := b.Services[route.Service]; ok && svc.Expose != nil && !*svc.Expose {
+			continue
+		}
+		path, params := openAPIPath(route)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		op := operation(b, doc, route, params)
+		(*item)[strings.ToLower(route.Method)] = op
+		if route.Service != "" && !slices.Contains(tags, route.Service) {
+			tags = append(tags, route.Service)
+		}
+	}
+	sort.Strings(tags)
+	for _, t := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: t})
+	}
+	return doc
+}
+
+// servers lists the configured server with the http base path, or the base
+// path alone when no port is set.
+func servers(b *ir.IRBundle) []Server {
+	base := b.Http.BasePath
+	srv := b.Config.Runtime.Service.Server
+	if srv.Port == 0 {
+		if base == "" {
+			return nil
+		}
+		return []Server{{URL: base}}
+	}
+	host := srv.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return []Server{{
+		URL:         "http://" + net.JoinHostPort(host, strconv.Itoa(srv.Port)) + base,
+		Description: "Configured server",
+	}}
+}
+
+func cors(h ir.IRHttpConfig) *CORS {
+	if h.Cors == nil || !*h.Cors {
+		return nil
+	}
+	return &CORS{
+		AllowedOrigins:   h.AllowedOrigins,
+		AllowedMethods:   h.AllowedMethods,
+		AllowedHeaders:   h.AllowedHeaders,
+		ExposeHeaders:    h.ExposeHeaders,
+		AllowCredentials: h.AllowCredentials,
+		MaxAge:           h.MaxAge,
+	}
+}
+
+// openAPIPath rewrites the route's segments in OpenAPI form, /users/{id},
+// and returns the path parameters they declare.
+func openAPIPath(route ir.IRRoute) (string, []Parameter) {
+	var sb strings.Builder
+	var params []Parameter
+	for _, seg := range route.Segments {
+		sb.WriteByte('/')
+		switch seg.Kind {
+		case ir.SegmentStatic:
+			sb.WriteString(seg.Literal)
+			continue
+		case ir.SegmentCatchAll, ir.SegmentWildcard:
+			sb.WriteString("{wildcard}")
+			params = append(params, Parameter{Name: "wildcard", In: "path", Required: true,
+				Description: "The rest of the path.", Schema: &Schema{Type: "string"}})
+			continue
+		}
+		sb.WriteString("{" + seg.Name + "}")
+		p := Parameter{Name: seg.Name, In: "path", Required: true, Schema: &Schema{Type: "string", Pattern: seg.Regex}}
+		params = append(params, p)
+	}
+	if sb.Len() == 0 {
+		return "/", params
+	}
+	return sb.String(), params
+}
+
+func operation(b *ir.IRBundle, doc *Document, route ir.IRRoute, params []Parameter) *Operation {
+	irOp := b.Operations[route.Operation]
+	op := &Operation{
+		OperationID: route.Operation,
+		Summary:     irOp.Description,
+		Parameters:  params,
+		Responses:   map[string]*Response{},
+	}
+	if route.Service != "" {
+		op.Tags = []string{route.Service}
+	}
+
+	model := ""
+	if svc, ok := b.Services[route.Service]; ok {
+		if _, ok := b.Models[svc.Model]; ok {
+			model = svc.Model
+		}
+	}
+	data := irOp.Data
+	if irOp.Kind != ir.OperationKindData || data == nil || model == "" {
+		op.Responses["200"] = &Response{Description: "Successful response."}
+		addRateLimitResponses(b, route, op)
+		return op
+	}
+
+	addModelSchemas(doc, b.Models[model])
+	if op.Summary == "" {
+		noun := model
+		if data.Target == "many" {
+			noun = flect.Pluralize(model)
+		}
+		op.Summary = flect.Capitalize(string(data.Action)) + " " + noun
+	}
+	if data.Action == ir.DataList {
+		query := jsonschema.ListQuery(b.Models[model], *data)
+		for _, name := range jsonschema.QueryOrder(*data) {
+			p := query.Properties[name]
+			desc := p.Description
+			p.Description = ""
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "query", Description: desc, Schema: p})
+		}
+	}
+	switch data.Action {
+	case ir.DataCreate:
+		op.RequestBody = jsonBody(ref(model + "Create"))
+	case ir.DataUpdate:
+		op.RequestBody = jsonBody(ref(model + "Update"))
+	}
+
+	switch {
+	case data.ReturnsList:
+		op.Responses["200"] = jsonResponse("The matching "+flect.Pluralize(model)+".", &Schema{Type: "array", Items: ref(model)})
+	case data.ReturnsEntity && data.Action == ir.DataCreate:
+		op.Responses["201"] = jsonResponse("The created "+model+".", ref(model))
+	case data.ReturnsEntity:
+		op.Responses["200"] = jsonResponse("The "+model+".", ref(model))
+	default:
+		op.Responses["204"] = &Response{Description: "Done."}
+	}
+	if op.RequestBody != nil {
+		op.Responses["400"] = jsonResponse("The request is invalid.", ref(errorSchema))
+	}
+	if len(params) > 0 && data.Target == "single" {
+		op.Responses["404"] = jsonResponse(model+" not found.", ref(errorSchema))
+	}
+	addRateLimitResponses(b, route, op)
+	return op
+}
+
+// addRateLimitResponses documents the response of every rate limit applied
+// to route, 429 unless the rate limit sets its own status code.
+func addRateLimitResponses(b *ir.IRBundle, route ir.IRRoute, op *Operation) {
+	for _, name := range route.RateLimits() {
+		rl, ok := b.RateLimits[name]
+		if !ok {
+			continue
+		}
+		code := strconv.Itoa(rl.StatusCode())
+		desc := "Rate limit '" + name + "' exceeded"
+		if rl.Limit.Requests > 0 {
+			desc += fmt.Sprintf(" (%d requests per %s)", rl.Limit.Requests, rl.Limit.Window)
+		}
+		desc += "."
+		if existing, ok := op.Responses[code]; ok {
+			existing.Description += " " + desc
+			continue
+		}
+		resp := &Response{Description: desc}
+		if rl.Action == ir.RateThrottle {
+			resp.Headers = map[string]*Header{"Retry-After": {
+				Description: "Seconds to wait before retrying.",
+				Schema:      &Schema{Type: "integer"},
+			}}
+		}
+		if rl.Response != nil && len(rl.Response.Body) > 0 {
+			body := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			for k := range rl.Response.Body {
+				body.Properties[k] = &Schema{Type: "string"}
+			}
+			resp.Content = map[string]*MediaType{jsonMedia: {Schema: body, Example: rl.Response.Body}}
+		} else {
+			resp.Content = map[string]*MediaType{jsonMedia: {Schema: ref(errorSchema)}}
+		}
+		op.Responses[code] = resp
+	}
+}
+
+// addModelSchemas adds the schemas of m once: m itself as returned, and
+// mCreate and mUpdate as accepted by the create and update operations.
+func addModelSchemas(doc *Document, m ir.IRModel) {
+	if _, ok := doc.Components.Schemas[m.Name]; ok {
+		return
+	}
+	doc.Components.Schemas[m.Name] = jsonschema.Model(m, jsonschema.Response)
+	doc.Components.Schemas[m.Name+"Create"] = jsonschema.Model(m, jsonschema.Create)
+	doc.Components.Schemas[m.Name+"Update"] = jsonschema.Model(m, jsonschema.Update)
+}
+
+func errorBody() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"statusCode": {Type: "integer"},
+			"error":      {Type: "string"},
+			"message":    {Type: "string"},
+		},
+		Required: []string{"message"},
+	}
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func jsonBody(s *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{jsonMedia: {Schema: s}}}
+}
+
+func jsonResponse(desc string, s *Schema) *Response {
+	return &Response{Description: desc, Content: map[string]*MediaType{jsonMedia: {Schema: s}}}
+}
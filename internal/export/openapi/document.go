@@ -0,0 +1,97 @@
+// Package openapi builds an OpenAPI 3.1 document from the IR.
+package openapi
+
+import "github.com/kwizyHQ/irex/internal/jsonschema"
+
+// Version is the OpenAPI version documents are written for.
+const Version = "3.1.0"
+
+// Document is the subset of an OpenAPI 3.1 document the IR can fill.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Tags       []Tag                `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+	// CORS carries the http block's CORS settings, which OpenAPI has no
+	// field for, as the x-cors extension.
+	CORS *CORS `json:"x-cors,omitempty"`
+}
+
+type Info struct {
+	Title       string   `json:"title"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	License     *License `json:"license,omitempty"`
+}
+
+type License struct {
+	Name string `json:"name"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem maps lower-case HTTP methods to their operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema  *Schema `json:"schema"`
+	Example any     `json:"example,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is a JSON Schema 2020-12 object, as OpenAPI 3.1 uses.
+type Schema = jsonschema.Schema
+
+type CORS struct {
+	AllowedOrigins   []string `json:"allowedOrigins,omitempty"`
+	AllowedMethods   []string `json:"allowedMethods,omitempty"`
+	AllowedHeaders   []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders    []string `json:"exposeHeaders,omitempty"`
+	AllowCredentials *bool    `json:"allowCredentials,omitempty"`
+	MaxAge           *int     `json:"maxAge,omitempty"`
+}
@@ -0,0 +1,265 @@
+// Package openapi maps an OpenAPI 3 document onto irex schema and service
+// specs.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// document is the part of an OpenAPI 3.0 or 3.1 document the importer
+// reads. Maps whose order shows in the specs keep it.
+type document struct {
+	Servers    []server          `json:"servers"`
+	Security   []json.RawMessage `json:"security"`
+	Paths      ordered[pathItem] `json:"paths"`
+	Components struct {
+		Schemas         ordered[*schema]           `json:"schemas"`
+		SecuritySchemes map[string]json.RawMessage `json:"securitySchemes"`
+	} `json:"components"`
+}
+
+type server struct {
+	URL string `json:"url"`
+}
+
+type pathItem struct {
+	Get     *operation `json:"get"`
+	Put     *operation `json:"put"`
+	Post    *operation `json:"post"`
+	Delete  *operation `json:"delete"`
+	Options *operation `json:"options"`
+	Head    *operation `json:"head"`
+	Patch   *operation `json:"patch"`
+	Trace   *operation `json:"trace"`
+}
+
+// methods returns the item's operations keyed by upper-case HTTP method,
+// in a fixed order.
+func (p pathItem) methods() []methodOperation {
+	var out []methodOperation
+	for _, m := range []methodOperation{
+		{"GET", p.Get}, {"POST", p.Post}, {"PUT", p.Put}, {"PATCH", p.Patch},
+		{"DELETE", p.Delete}, {"HEAD", p.Head}, {"OPTIONS", p.Options}, {"TRACE", p.Trace},
+	} {
+		if m.op != nil {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+type methodOperation struct {
+	method string
+	op     *operation
+}
+
+type operation struct {
+	OperationID string            `json:"operationId"`
+	Summary     string            `json:"summary"`
+	Description string            `json:"description"`
+	Parameters  []parameter       `json:"parameters"`
+	RequestBody *body             `json:"requestBody"`
+	Responses   map[string]*body  `json:"responses"`
+	Security    []json.RawMessage `json:"security"`
+	Callbacks   map[string]any    `json:"callbacks"`
+}
+
+type parameter struct {
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+// body is a request body or a response; both carry content by media type.
+type body struct {
+	Content map[string]mediaType `json:"content"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+// schema is a JSON Schema object as OpenAPI 3.0 and 3.1 write it.
+type schema struct {
+	Ref         string           `json:"$ref"`
+	Type        schemaType       `json:"type"`
+	Format      string           `json:"format"`
+	Description string           `json:"description"`
+	Enum        []any            `json:"enum"`
+	Properties  ordered[*schema] `json:"properties"`
+	Required    []string         `json:"required"`
+	Items       *schema          `json:"items"`
+	MinLength   *int             `json:"minLength"`
+	MaxLength   *int             `json:"maxLength"`
+	Minimum     *float64         `json:"minimum"`
+	Maximum     *float64         `json:"maximum"`
+	Pattern     string           `json:"pattern"`
+	Default     json.RawMessage  `json:"default"`
+	Nullable    bool             `json:"nullable"`
+	ReadOnly    bool             `json:"readOnly"`
+	WriteOnly   bool             `json:"writeOnly"`
+	AllOf       []*schema        `json:"allOf"`
+	OneOf       []*schema        `json:"oneOf"`
+	AnyOf       []*schema        `json:"anyOf"`
+}
+
+// schemaType is a schema's type: one name in 3.0, one or a list in 3.1.
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaType{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// primary returns the type other than "null", or "" when there is none or
+// several.
+func (t schemaType) primary() string {
+	var out string
+	for _, name := range t {
+		if name == "null" {
+			continue
+		}
+		if out != "" {
+			return ""
+		}
+		out = name
+	}
+	return out
+}
+
+func (t schemaType) nullable() bool {
+	for _, name := range t {
+		if name == "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// ordered is a JSON object decoded with the order of its keys.
+type ordered[T any] struct {
+	keys   []string
+	values map[string]T
+}
+
+func (o *ordered[T]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("expected an object")
+	}
+	o.values = map[string]T{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if _, ok := o.values[key]; !ok {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = v
+	}
+	return nil
+}
+
+func (o ordered[T]) get(key string) (T, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// parse reads an OpenAPI document written as YAML or JSON.
+func parse(data []byte) (*document, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("the document is empty")
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, root.Content[0]); err != nil {
+		return nil, err
+	}
+	var head struct {
+		OpenAPI string `json:"openapi"`
+		Swagger string `json:"swagger"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &head); err != nil {
+		return nil, err
+	}
+	switch {
+	case head.Swagger != "":
+		return nil, fmt.Errorf("swagger %s documents are not supported; convert them to OpenAPI 3 first", head.Swagger)
+	case !strings.HasPrefix(head.OpenAPI, "3."):
+		return nil, fmt.Errorf("not an OpenAPI 3 document")
+	}
+	doc := &document{}
+	if err := json.Unmarshal(buf.Bytes(), doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// writeJSON writes a YAML node as JSON, keeping the order of mapping keys,
+// which decoding YAML into Go maps would lose.
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return writeJSON(buf, n.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(n.Content[i].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		buf.Write(data)
+	}
+	return nil
+}
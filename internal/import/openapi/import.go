@@ -0,0 +1,192 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Result is an OpenAPI document mapped onto irex specs.
+type Result struct {
+	// Schema and Service are the formatted schema and service spec files.
+	Schema  []byte
+	Service []byte
+	// Models, Services and Operations count the model blocks, model-backed
+	// service blocks and custom operation blocks written.
+	Models     int
+	Services   int
+	Operations int
+	// Unmapped lists, one line each, what the specs leave out.
+	Unmapped []string
+}
+
+// Import maps the OpenAPI 3 document in data, YAML or JSON, onto a schema
+// and a service spec. Object component schemas become models; paths with
+// the shape of CRUD operations on a model become model services, and the
+// other operations custom ones.
+func Import(data []byte) (*Result, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	im := &importer{doc: doc, models: map[string]string{}, inputs: map[string]string{}}
+	models := im.mapModels()
+	services := im.mapServices()
+
+	res := &Result{
+		Schema:   writeSchema(models),
+		Service:  writeService(services),
+		Models:   len(models),
+		Services: len(services.Services),
+		Unmapped: im.unmapped,
+	}
+	res.Operations = len(services.Operations)
+	for _, s := range services.Services {
+		res.Operations += len(s.Operations)
+	}
+	return res, nil
+}
+
+func writeSchema(models []symbols.Model) []byte {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("models", nil).Body()
+	for i, m := range models {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		mb := body.AppendNewBlock("model", []string{m.Name}).Body()
+		for _, field := range m.Fields {
+			writeField(mb, field)
+		}
+		if m.Config != nil && m.Config.Timestamps {
+			mb.AppendNewBlock("config", nil).Body().SetAttributeValue("timestamps", cty.True)
+		}
+	}
+	return hclwrite.Format(f.Bytes())
+}
+
+// writeField writes the attributes in the order irex format --canonical
+// gives them.
+func writeField(body *hclwrite.Body, f symbols.ModelField) {
+	fb := body.AppendNewBlock("field", []string{f.Name}).Body()
+	setString(fb, "type", f.Type)
+	if f.Required {
+		fb.SetAttributeValue("required", cty.True)
+	}
+	setInt(fb, "minlength", f.MinLength)
+	setInt(fb, "maxlength", f.MaxLength)
+	setInt(fb, "min", f.Min)
+	setInt(fb, "max", f.Max)
+	if f.Default != cty.NilVal {
+		fb.SetAttributeValue("default", f.Default)
+	}
+	setString(fb, "match", f.Match)
+	setString(fb, "visibility", f.Visibility)
+	for _, nested := range f.Fields {
+		writeField(fb, nested)
+	}
+	setString(fb, "description", f.Description)
+}
+
+// writeService writes only the services block: security schemes and rate
+// limits are not mapped, and empty policies and rate_limits blocks may be
+// left out.
+func writeService(block *symbols.ServicesBlock) []byte {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("services", nil).Body()
+	setString(body, "base_path", block.BasePath)
+	for _, op := range block.Operations {
+		writeOperation(body, op)
+	}
+	for _, s := range block.Services {
+		sb := body.AppendNewBlock("service", []string{s.Name}).Body()
+		setString(sb, "model", s.Model)
+		if s.Pagination != nil {
+			sb.SetAttributeValue("pagination", cty.BoolVal(*s.Pagination))
+		}
+		setString(sb, "path", s.Path)
+		ops := make([]cty.Value, len(s.CrudOperations))
+		for i, op := range s.CrudOperations {
+			ops[i] = cty.StringVal(op)
+		}
+		sb.SetAttributeValue("crud_operations", cty.ListVal(ops))
+		for _, op := range s.Operations {
+			writeOperation(sb, op)
+		}
+	}
+	return hclwrite.Format(f.Bytes())
+}
+
+// MergeService adds the services and operations of the imported service
+// spec to the services block of existing, a project's service spec, and
+// returns the formatted result with notes on what was left out. The
+// imported base_path is only kept when existing sets none. Services and
+// operations existing already declares are an error.
+func MergeService(existing, imported []byte) ([]byte, []string, error) {
+	dst, diags := hclwrite.ParseConfig(existing, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	src, diags := hclwrite.ParseConfig(imported, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	from := src.Body().FirstMatchingBlock("services", nil)
+	if from == nil {
+		return existing, nil, nil
+	}
+	into := dst.Body().FirstMatchingBlock("services", nil)
+	if into == nil {
+		dst.Body().AppendNewline()
+		into = dst.Body().AppendNewBlock("services", nil)
+	}
+
+	var notes []string
+	if base := from.Body().GetAttribute("base_path"); base != nil {
+		switch own := into.Body().GetAttribute("base_path"); {
+		case own == nil:
+			into.Body().SetAttributeRaw("base_path", base.Expr().BuildTokens(nil))
+		case !bytes.Equal(bytes.TrimSpace(own.Expr().BuildTokens(nil).Bytes()), bytes.TrimSpace(base.Expr().BuildTokens(nil).Bytes())):
+			notes = append(notes, "servers: the imported services use the project's base_path, not "+string(bytes.TrimSpace(base.Expr().BuildTokens(nil).Bytes())))
+		}
+	}
+	declared := map[string]bool{}
+	for _, b := range into.Body().Blocks() {
+		if len(b.Labels()) > 0 {
+			declared[b.Type()+" "+b.Labels()[0]] = true
+		}
+	}
+	for _, b := range from.Body().Blocks() {
+		if len(b.Labels()) > 0 && declared[b.Type()+" "+b.Labels()[0]] {
+			return nil, nil, fmt.Errorf("%s %q is already declared", b.Type(), b.Labels()[0])
+		}
+	}
+	for _, b := range from.Body().Blocks() {
+		into.Body().AppendNewline()
+		into.Body().AppendBlock(b)
+	}
+	return hclwrite.Format(dst.Bytes()), notes, nil
+}
+
+func writeOperation(body *hclwrite.Body, op symbols.Operation) {
+	ob := body.AppendNewBlock("operation", []string{op.Name}).Body()
+	setString(ob, "method", op.Method)
+	setString(ob, "path", op.Path)
+	setString(ob, "description", op.Description)
+}
+
+func setString(body *hclwrite.Body, name, v string) {
+	if v != "" {
+		body.SetAttributeValue(name, cty.StringVal(v))
+	}
+}
+
+func setInt(body *hclwrite.Body, name string, v *int) {
+	if v != nil {
+		body.SetAttributeValue(name, cty.NumberIntVal(int64(*v)))
+	}
+}
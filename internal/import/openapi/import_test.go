@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/core/validate"
+)
+
+const blogSpec = `openapi: 3.0.3
+info:
+  title: Blog
+  version: 1.0.0
+servers:
+  - url: https://api.example.com/v1
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/User"
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/User"
+      responses:
+        "201":
+          description: created
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+    delete:
+      responses:
+        "204":
+          description: deleted
+  /health:
+    get:
+      operationId: health
+      summary: Liveness probe
+      responses:
+        "200":
+          description: ok
+components:
+  schemas:
+    User:
+      type: object
+      required: [email]
+      properties:
+        email:
+          type: string
+          maxLength: 120
+        age:
+          type: integer
+          minimum: 0
+`
+
+// TestImportRoundTrip decodes and validates the specs an import writes.
+func TestImportRoundTrip(t *testing.T) {
+	res, err := Import([]byte(blogSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Models != 1 || res.Services != 1 || res.Operations != 1 {
+		t.Errorf("counted %d models, %d services, %d operations; want 1 each", res.Models, res.Services, res.Operations)
+	}
+
+	var schema symbols.ModelsSpec
+	if diags := ast.ParseFromHCLContent("blog.hcl", string(res.Schema), &schema); len(diags) > 0 {
+		t.Fatalf("schema does not decode: %+v\n%s", diags, res.Schema)
+	}
+	if diags := validate.ValidateSchema(&schema); len(diags) > 0 {
+		t.Errorf("schema does not validate: %+v", diags)
+	}
+	models := schema.ModelsBlock.Models
+	if len(models) != 1 || models[0].Name != "User" || len(models[0].Fields) != 2 {
+		t.Fatalf("want model User with two fields, got %+v", models)
+	}
+	email, age := models[0].Fields[0], models[0].Fields[1]
+	if email.Name != "email" || !email.Required || email.MaxLength == nil || *email.MaxLength != 120 {
+		t.Errorf("email = %+v", email)
+	}
+	if age.Name != "age" || age.Type != "int" || age.Min == nil || *age.Min != 0 {
+		t.Errorf("age = %+v", age)
+	}
+
+	if bytes.Contains(res.Service, []byte("policies")) || bytes.Contains(res.Service, []byte("rate_limits")) {
+		t.Errorf("service spec has empty policies or rate_limits blocks:\n%s", res.Service)
+	}
+	var service symbols.ServiceDefinition
+	if diags := ast.ParseFromHCLContent("blog.hcl", string(res.Service), &service); len(diags) > 0 {
+		t.Fatalf("service spec does not decode: %+v\n%s", diags, res.Service)
+	}
+	if diags := validate.ValidateService(&service); len(diags) > 0 {
+		t.Errorf("service spec does not validate: %+v", diags)
+	}
+	block := service.Services
+	if block.BasePath != "/v1" {
+		t.Errorf("base_path = %q, want /v1", block.BasePath)
+	}
+	if len(block.Services) != 1 || block.Services[0].Name != "users" || block.Services[0].Model != "User" || block.Services[0].Path != "/users" {
+		t.Fatalf("want service users on /users, got %+v", block.Services)
+	}
+	if ops := block.Services[0].CrudOperationsSet; ops.LengthInt() != 4 {
+		t.Errorf("crud_operations = %#v, want create, read, delete and list", ops)
+	}
+	if len(block.Operations) != 1 || block.Operations[0].Name != "health" || block.Operations[0].Method != "GET" || block.Operations[0].Path != "/health" {
+		t.Errorf("want operation health on GET /health, got %+v", block.Operations)
+	}
+}
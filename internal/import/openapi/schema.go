@@ -0,0 +1,438 @@
+package openapi
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// importer carries the document and what has been mapped so far.
+type importer struct {
+	doc *document
+	// models maps component schema names to the model they became.
+	models map[string]string
+	// inputs maps request body schemas that a model covers to the model.
+	inputs   map[string]string
+	unmapped []string
+}
+
+func (im *importer) note(format string, args ...any) {
+	im.unmapped = append(im.unmapped, fmt.Sprintf(format, args...))
+}
+
+// component resolves a $ref to a component schema.
+func (im *importer) component(ref string) (string, *schema, bool) {
+	name, ok := strings.CutPrefix(ref, schemaRefPrefix)
+	if !ok {
+		return "", nil, false
+	}
+	s, ok := im.doc.Components.Schemas.get(name)
+	return name, s, ok && s != nil
+}
+
+// isObject reports whether s describes an object with properties.
+func isObject(s *schema) bool {
+	if s == nil {
+		return false
+	}
+	if len(s.AllOf) > 0 {
+		return true
+	}
+	t := s.Type.primary()
+	return t == "object" || (t == "" && len(s.Properties.keys) > 0)
+}
+
+// mapModels maps the object component schemas to models. Schemas only used
+// by error responses are not models, and neither are request bodies that
+// a model covers, such as the <Model>Create and <Model>Update schemas irex
+// export writes.
+func (im *importer) mapModels() []symbols.Model {
+	use := im.usage()
+	names := im.doc.Components.Schemas.keys
+	for _, name := range names {
+		s, _ := im.doc.Components.Schemas.get(name)
+		switch {
+		case !isObject(s):
+			continue
+		case use.errors[name] && !use.responses[name] && !use.requests[name] && !use.embedded[name]:
+			im.note("schema %s: only used by error responses", name)
+		default:
+			im.models[name] = modelName(name)
+		}
+	}
+	for _, name := range names {
+		if im.models[name] == "" || use.responses[name] || use.embedded[name] {
+			continue
+		}
+		if m := im.coveringModel(name); m != "" {
+			im.inputs[name] = m
+			delete(im.models, name)
+		}
+	}
+
+	var out []symbols.Model
+	for _, name := range names {
+		s, _ := im.doc.Components.Schemas.get(name)
+		if im.models[name] == "" {
+			if s != nil && !isObject(s) {
+				im.note("schema %s: not an object (%s); use it as a field type by hand", name, describe(s))
+			}
+			continue
+		}
+		if im.models[name] != name {
+			im.note("schema %s: imported as model %s", name, im.models[name])
+		}
+		m := symbols.Model{Name: im.models[name]}
+		var timestamps bool
+		for _, f := range im.fields(s, name, []string{name}) {
+			switch {
+			case f.Name == "id" || f.Name == "_id":
+				// the database provides ids
+			case f.Name == "createdAt" || f.Name == "updatedAt":
+				timestamps = true
+			default:
+				m.Fields = append(m.Fields, f)
+			}
+		}
+		if timestamps {
+			m.Config = &symbols.ModelConfig{Timestamps: true}
+		}
+		if len(m.Fields) == 0 {
+			im.note("schema %s: has no fields irex can keep", name)
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// usage records where the document references each component schema.
+type usage struct {
+	errors    map[string]bool // by an error response
+	responses map[string]bool // by another response
+	requests  map[string]bool // by a request body
+	embedded  map[string]bool // by a property, array item or alternative of a schema
+}
+
+func (im *importer) usage() usage {
+	u := usage{errors: map[string]bool{}, responses: map[string]bool{}, requests: map[string]bool{}, embedded: map[string]bool{}}
+	mark := func(set map[string]bool, content map[string]mediaType) {
+		for _, mt := range content {
+			s := mt.Schema
+			if s != nil && s.Items != nil {
+				s = s.Items
+			}
+			if s == nil {
+				continue
+			}
+			if name, ok := strings.CutPrefix(s.Ref, schemaRefPrefix); ok {
+				set[name] = true
+			}
+		}
+	}
+	for _, path := range im.doc.Paths.keys {
+		item, _ := im.doc.Paths.get(path)
+		for _, m := range item.methods() {
+			if m.op.RequestBody != nil {
+				mark(u.requests, m.op.RequestBody.Content)
+			}
+			for status, resp := range m.op.Responses {
+				if resp == nil {
+					continue
+				}
+				set := u.responses
+				if status == "default" || strings.HasPrefix(status, "4") || strings.HasPrefix(status, "5") {
+					set = u.errors
+				}
+				mark(set, resp.Content)
+			}
+		}
+	}
+	for _, name := range im.doc.Components.Schemas.keys {
+		s, _ := im.doc.Components.Schemas.get(name)
+		walkEmbedded(s, func(ref string) { u.embedded[ref] = true })
+	}
+	return u
+}
+
+// walkEmbedded calls fn with the components s embeds as a property, an
+// array item or an alternative. allOf references extend s instead.
+func walkEmbedded(s *schema, fn func(string)) {
+	if s == nil {
+		return
+	}
+	for _, k := range s.Properties.keys {
+		walkRefs(s.Properties.values[k], fn)
+	}
+	walkRefs(s.Items, fn)
+	for _, alt := range slices.Concat(s.OneOf, s.AnyOf) {
+		walkRefs(alt, fn)
+	}
+	for _, part := range s.AllOf {
+		walkEmbedded(part, fn)
+	}
+}
+
+// walkRefs calls fn with every component s refers to, at any depth.
+func walkRefs(s *schema, fn func(string)) {
+	if s == nil {
+		return
+	}
+	if name, ok := strings.CutPrefix(s.Ref, schemaRefPrefix); ok {
+		fn(name)
+	}
+	for _, k := range s.Properties.keys {
+		walkRefs(s.Properties.values[k], fn)
+	}
+	walkRefs(s.Items, fn)
+	for _, part := range slices.Concat(s.AllOf, s.OneOf, s.AnyOf) {
+		walkRefs(part, fn)
+	}
+}
+
+// coveringModel returns another model having every property of the schema
+// name, which is then taken as that model's request body. Models named
+// name minus a Create or Update suffix come first.
+func (im *importer) coveringModel(name string) string {
+	props := im.propertyNames(name, nil)
+	if len(props) == 0 {
+		return ""
+	}
+	candidates := slices.Clone(im.doc.Components.Schemas.keys)
+	for _, suffix := range []string{"Create", "Update"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			candidates = slices.Insert(candidates, 0, base)
+		}
+	}
+	for _, other := range candidates {
+		if other == name || im.models[other] == "" {
+			continue
+		}
+		have := im.propertyNames(other, nil)
+		if !slices.ContainsFunc(props, func(p string) bool { return !slices.Contains(have, p) }) {
+			return im.models[other]
+		}
+	}
+	return ""
+}
+
+// propertyNames lists the properties of the component schema name,
+// including those of its allOf parts.
+func (im *importer) propertyNames(name string, stack []string) []string {
+	s, ok := im.doc.Components.Schemas.get(name)
+	if !ok || s == nil || slices.Contains(stack, name) {
+		return nil
+	}
+	stack = append(stack, name)
+	var out []string
+	var add func(s *schema)
+	add = func(s *schema) {
+		if s == nil {
+			return
+		}
+		if ref, ok := strings.CutPrefix(s.Ref, schemaRefPrefix); ok {
+			out = append(out, im.propertyNames(ref, stack)...)
+			return
+		}
+		out = append(out, s.Properties.keys...)
+		for _, part := range s.AllOf {
+			add(part)
+		}
+	}
+	add(s)
+	return out
+}
+
+// flatten merges the properties and required lists of s and its allOf
+// parts, resolving references.
+func (im *importer) flatten(s *schema, where string, stack []string) (props ordered[*schema], required []string) {
+	props.values = map[string]*schema{}
+	var add func(s *schema, stack []string)
+	add = func(s *schema, stack []string) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			name, target, ok := im.component(s.Ref)
+			if !ok || slices.Contains(stack, name) {
+				im.note("%s: reference %s cannot be followed", where, s.Ref)
+				return
+			}
+			add(target, append(stack, name))
+			return
+		}
+		for _, k := range s.Properties.keys {
+			if _, ok := props.values[k]; !ok {
+				props.keys = append(props.keys, k)
+			}
+			props.values[k] = s.Properties.values[k]
+		}
+		required = append(required, s.Required...)
+		for _, part := range s.AllOf {
+			add(part, stack)
+		}
+	}
+	add(s, stack)
+	return props, required
+}
+
+// fields maps the properties of the object s to model fields. stack holds
+// the component schemas being expanded, to stop at recursive ones.
+func (im *importer) fields(s *schema, where string, stack []string) []symbols.ModelField {
+	props, required := im.flatten(s, where, stack)
+	var out []symbols.ModelField
+	for _, name := range props.keys {
+		f := im.field(name, props.values[name], where+"."+name, stack)
+		f.Required = f.Required && slices.Contains(required, name)
+		out = append(out, f)
+	}
+	return out
+}
+
+// field maps one property. Required is set when the property may not be
+// null; fields clears it for properties the object does not require.
+func (im *importer) field(name string, s *schema, where string, stack []string) symbols.ModelField {
+	f := symbols.ModelField{Name: name, Required: true}
+	if s == nil {
+		f.Type = "object"
+		return f
+	}
+	if s.Ref != "" {
+		ref, target, ok := im.component(s.Ref)
+		switch {
+		case !ok:
+			im.note("%s: reference %s cannot be followed; mapped as object", where, s.Ref)
+			f.Type = "object"
+			return f
+		case slices.Contains(stack, ref):
+			im.note("%s: %s refers back to itself; mapped as object", where, ref)
+			f.Type = "object"
+			return f
+		}
+		inner := im.field(name, target, where, append(slices.Clone(stack), ref))
+		if s.Description != "" {
+			inner.Description = s.Description
+		}
+		return inner
+	}
+
+	f.Description = s.Description
+	if s.Nullable || s.Type.nullable() {
+		f.Required = false
+	}
+	if s.WriteOnly {
+		f.Visibility = "private"
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		im.note("%s: oneOf and anyOf are not supported; mapped as object", where)
+		f.Type = "object"
+		return f
+	}
+
+	switch t := s.Type.primary(); {
+	case isObject(s):
+		if f.Fields = im.fields(s, where, stack); len(f.Fields) == 0 {
+			f.Type = "object"
+		}
+	case t == "string":
+		f.Type = "string"
+		if s.Format == "date-time" || s.Format == "date" {
+			f.Type = "date"
+		}
+		f.MinLength, f.MaxLength = s.MinLength, s.MaxLength
+		f.Match = s.Pattern
+		if len(s.Enum) > 0 {
+			f.Match = enumPattern(s.Enum)
+		}
+	case t == "integer" || t == "number":
+		f.Type = "number"
+		if t == "integer" {
+			f.Type = "int"
+		}
+		f.Min = im.bound(s.Minimum, where, "minimum")
+		f.Max = im.bound(s.Maximum, where, "maximum")
+	case t == "boolean":
+		f.Type = "bool"
+	case t == "array":
+		f.Type = "array"
+		if s.Items != nil && s.Items.Type.primary() == "string" && s.Items.Format == "" && len(s.Items.Enum) == 0 {
+			f.Type = "string[]"
+		} else if s.Items != nil {
+			im.note("%s: array of %s; the item type is not kept", where, describe(s.Items))
+		}
+	case t == "":
+		f.Type = "object"
+	default:
+		im.note("%s: type %q is not supported; mapped as object", where, t)
+		f.Type = "object"
+	}
+	if len(s.Default) > 0 && string(s.Default) != "null" {
+		f.Default = im.defaultValue(s, where)
+	}
+	return f
+}
+
+// bound converts a minimum or maximum to the integer irex stores.
+func (im *importer) bound(v *float64, where, what string) *int {
+	if v == nil {
+		return nil
+	}
+	if *v != math.Trunc(*v) {
+		im.note("%s: %s %v is not an integer and was dropped", where, what, *v)
+		return nil
+	}
+	i := int(*v)
+	return &i
+}
+
+func (im *importer) defaultValue(s *schema, where string) cty.Value {
+	ty, err := ctyjson.ImpliedType(s.Default)
+	if err == nil {
+		if v, err := ctyjson.Unmarshal(s.Default, ty); err == nil {
+			return v
+		}
+	}
+	im.note("%s: default %s was dropped", where, s.Default)
+	return cty.NilVal
+}
+
+// enumPattern returns a regular expression matching exactly the values.
+func enumPattern(values []any) string {
+	alts := make([]string, len(values))
+	for i, v := range values {
+		alts[i] = regexp.QuoteMeta(fmt.Sprint(v))
+	}
+	return "^(" + strings.Join(alts, "|") + ")$"
+}
+
+// describe names the kind of value s holds, for notes.
+func describe(s *schema) string {
+	switch {
+	case s.Ref != "":
+		return strings.TrimPrefix(s.Ref, schemaRefPrefix)
+	case len(s.Enum) > 0:
+		return "enum"
+	case s.Type.primary() != "":
+		return s.Type.primary()
+	}
+	return "any"
+}
+
+// modelName makes name usable as a model name: letters, digits and
+// underscores only.
+func modelName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, name)
+}
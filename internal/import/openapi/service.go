@@ -0,0 +1,322 @@
+package openapi
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/flect"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+)
+
+// crudOrder is the order crud_operations are written in.
+var crudOrder = []string{"create", "read", "update", "delete", "list"}
+
+// route is one operation of the document.
+type route struct {
+	method string
+	path   string // irex form, /pets/:id
+	op     *operation
+}
+
+// paramSegment matches an OpenAPI path parameter segment, {id}.
+var paramSegment = regexp.MustCompile(`\{([^}/]+)\}`)
+
+// irexPath rewrites /pets/{petId} as /pets/:petId.
+func irexPath(path string) string {
+	return paramSegment.ReplaceAllString(path, ":$1")
+}
+
+// basePath returns the path of the first server URL, which becomes
+// services.base_path. Server variables are left as they are.
+func (im *importer) basePath() string {
+	if len(im.doc.Servers) == 0 {
+		return ""
+	}
+	url := im.doc.Servers[0].URL
+	if _, rest, ok := strings.Cut(url, "://"); ok {
+		url = "/"
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			url = rest[i:]
+		}
+	}
+	url = strings.TrimRight(url, "/")
+	if len(im.doc.Servers) > 1 {
+		im.note("servers: only the first server's path is used as base_path")
+	}
+	return url
+}
+
+// mapServices groups the paths into model services where they have the
+// shape of CRUD operations on a model, and custom operations otherwise.
+func (im *importer) mapServices() *symbols.ServicesBlock {
+	out := &symbols.ServicesBlock{BasePath: im.basePath()}
+	byPath := map[string][]route{}
+	var paths []string
+	for _, p := range im.doc.Paths.keys {
+		item, _ := im.doc.Paths.get(p)
+		for _, m := range item.methods() {
+			byPath[p] = append(byPath[p], route{method: m.method, path: irexPath(p), op: m.op})
+		}
+		paths = append(paths, p)
+	}
+
+	used := map[*operation]bool{}
+	names := map[string]bool{}
+	for _, p := range paths {
+		svc, ops := im.crudService(p, byPath, paths)
+		if svc == nil {
+			continue
+		}
+		svc.Name = unique(names, serviceName(p))
+		out.Services = append(out.Services, *svc)
+		for _, op := range ops {
+			used[op] = true
+		}
+	}
+
+	opNames := map[string]bool{}
+	var custom int
+	for _, p := range paths {
+		for _, r := range byPath[p] {
+			if used[r.op] {
+				continue
+			}
+			op := symbols.Operation{
+				Name:        unique(opNames, operationName(r)),
+				Method:      r.method,
+				Path:        r.path,
+				Description: firstLine(r.op.Summary, r.op.Description),
+			}
+			custom++
+			if i := ownerService(out.Services, r.path); i >= 0 {
+				out.Services[i].Operations = append(out.Services[i].Operations, op)
+			} else {
+				out.Operations = append(out.Operations, op)
+			}
+		}
+	}
+	switch {
+	case custom == 1:
+		im.note("1 custom operation keeps its method and path only; its request and response bodies are not mapped")
+	case custom > 1:
+		im.note("%d custom operations keep their method and path only; their request and response bodies are not mapped", custom)
+	}
+	if len(im.doc.Security) > 0 || len(im.doc.Components.SecuritySchemes) > 0 {
+		im.note("security: security schemes and requirements are not mapped; declare policies for them")
+	}
+	for _, p := range paths {
+		for _, r := range byPath[p] {
+			if len(r.op.Callbacks) > 0 {
+				im.note("%s %s: callbacks are not mapped", r.method, p)
+			}
+		}
+	}
+	return out
+}
+
+// crudService returns a model service for the collection path p when p and
+// p/{param} carry at least two CRUD operations on one model, with the
+// operations it takes.
+func (im *importer) crudService(p string, byPath map[string][]route, paths []string) (*symbols.Service, []*operation) {
+	if strings.HasSuffix(p, "}") {
+		return nil, nil
+	}
+	var itemPath, param string
+	for _, q := range paths {
+		rest, ok := strings.CutPrefix(q, strings.TrimRight(p, "/")+"/")
+		if m := paramSegment.FindStringSubmatch(rest); ok && m != nil && m[0] == rest {
+			itemPath, param = q, m[1]
+			break
+		}
+	}
+
+	crud := map[string]route{}
+	for _, r := range byPath[p] {
+		switch r.method {
+		case "POST":
+			crud["create"] = r
+		case "GET":
+			crud["list"] = r
+		}
+	}
+	var put *route
+	for _, r := range byPath[itemPath] {
+		switch r.method {
+		case "GET":
+			crud["read"] = r
+		case "PATCH":
+			crud["update"] = r
+		case "PUT":
+			put = &r
+		case "DELETE":
+			crud["delete"] = r
+		}
+	}
+	if _, ok := crud["update"]; !ok && put != nil {
+		crud["update"] = *put
+	}
+
+	model := im.crudModel(crud)
+	if model == "" || len(crud) < 2 {
+		return nil, nil
+	}
+	svc := &symbols.Service{Model: model, Path: irexPath(p)}
+	var ops []*operation
+	for _, action := range crudOrder {
+		if r, ok := crud[action]; ok {
+			svc.CrudOperations = append(svc.CrudOperations, action)
+			ops = append(ops, r.op)
+		}
+	}
+	if put != nil && crud["update"].op == put.op {
+		im.note("PUT %s: mapped to the update operation, which irex serves as PATCH", itemPath)
+	}
+	_, read := crud["read"]
+	_, update := crud["update"]
+	_, del := crud["delete"]
+	if param != "id" && (read || update || del) {
+		im.note("%s: irex names the item parameter :id, not :%s", itemPath, param)
+	}
+	if list, ok := crud["list"]; ok && paginated(list.op) {
+		paginate := true
+		svc.Pagination = &paginate
+	}
+	return svc, ops
+}
+
+// crudModel returns the model the CRUD operations return, if they agree
+// on one, or else the one their request bodies write.
+func (im *importer) crudModel(crud map[string]route) string {
+	var returned, written []string
+	for _, action := range crudOrder {
+		r, ok := crud[action]
+		if !ok {
+			continue
+		}
+		if r.op.RequestBody != nil && (action == "create" || action == "update") {
+			if m := im.modelOf(jsonSchema(r.op.RequestBody)); m != "" {
+				written = append(written, m)
+			}
+		}
+		for _, status := range []string{"200", "201"} {
+			if resp := r.op.Responses[status]; resp != nil {
+				if m := im.modelOf(jsonSchema(resp)); m != "" {
+					returned = append(returned, m)
+				}
+			}
+		}
+	}
+	for _, models := range [][]string{returned, written} {
+		if len(models) == 0 {
+			continue
+		}
+		if slices.ContainsFunc(models, func(m string) bool { return m != models[0] }) {
+			return ""
+		}
+		return models[0]
+	}
+	return ""
+}
+
+// modelOf returns the model s refers to, directly, through an array or as
+// the create or update body of the model.
+func (im *importer) modelOf(s *schema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Type.primary() == "array" && s.Items != nil {
+		s = s.Items
+	}
+	name, ok := strings.CutPrefix(s.Ref, schemaRefPrefix)
+	if !ok {
+		return ""
+	}
+	if m := im.models[name]; m != "" {
+		return m
+	}
+	return im.inputs[name]
+}
+
+func jsonSchema(b *body) *schema {
+	for mt, content := range b.Content {
+		if strings.Contains(mt, "json") {
+			return content.Schema
+		}
+	}
+	return nil
+}
+
+func paginated(op *operation) bool {
+	for _, p := range op.Parameters {
+		if p.In == "query" && (p.Name == "page" || p.Name == "limit") {
+			return true
+		}
+	}
+	return false
+}
+
+// ownerService returns the index of the service whose path prefixes path,
+// the longest one, or -1.
+func ownerService(services []symbols.Service, path string) int {
+	best := -1
+	for i, s := range services {
+		if (path == s.Path || strings.HasPrefix(path, s.Path+"/")) && (best < 0 || len(s.Path) > len(services[best].Path)) {
+			best = i
+		}
+	}
+	return best
+}
+
+// serviceName names the service of a collection path after its last
+// segment: /v1/blog-posts becomes blog_posts.
+func serviceName(p string) string {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	name := flect.Underscore(segments[len(segments)-1])
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+// operationName uses the operationId, or derives a name from the method and
+// path: GET /pets/{id}/photos becomes getPetsIdPhotos.
+func operationName(r route) string {
+	if id := r.op.OperationID; id != "" {
+		return strings.Map(func(c rune) rune {
+			if c == '_' || c == '.' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+				return c
+			}
+			return '_'
+		}, id)
+	}
+	words := []string{strings.ToLower(r.method)}
+	for _, seg := range strings.Split(r.path, "/") {
+		if seg = strings.TrimLeft(seg, ":"); seg != "" {
+			words = append(words, seg)
+		}
+	}
+	return flect.Camelize(strings.Join(words, "_"))
+}
+
+// unique returns name, or name_2, name_3... when it is taken, and takes it.
+func unique(taken map[string]bool, name string) string {
+	out := name
+	for i := 2; taken[out]; i++ {
+		out = name + "_" + strconv.Itoa(i)
+	}
+	taken[out] = true
+	return out
+}
+
+// firstLine returns the first line of the first non-empty text.
+func firstLine(texts ...string) string {
+	for _, t := range texts {
+		if t = strings.TrimSpace(t); t != "" {
+			line, _, _ := strings.Cut(t, "\n")
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
@@ -30,6 +30,7 @@ type IRProject struct {
 // ------------------------------------------------------------
 
 type IRPaths struct {
+	Root           string // absolute path of the directory holding irex.hcl
 	Specifications string // absolute path
 	Templates      string // absolute path
 	Output         string // absolute path
@@ -82,8 +83,25 @@ type IRRuntimeSchema struct {
 }
 
 type IRDatabaseConfig struct {
-	URI string // resolved value (no env refs)
-	DB  string
+	URI IRValue
+	DB  IRValue
+}
+
+// IRValue is a setting the generated code either embeds as a literal fixed at
+// build time or reads from the environment at runtime.
+type IRValue struct {
+	Literal    string // build-time value, used when Env is empty
+	Env        string // environment variable read at runtime
+	Default    string // fallback for Env when HasDefault
+	HasDefault bool
+	// Secret marks Env as a secret(): it is only ever looked up at runtime
+	// and never has a default.
+	Secret bool
+}
+
+// IsRuntime reports whether the value is read from the environment at runtime.
+func (v IRValue) IsRuntime() bool {
+	return v.Env != ""
 }
 
 // ------------------------------------------------------------
@@ -110,4 +128,5 @@ type IRServerConfig struct {
 type IRMeta struct {
 	CreatedAt        time.Time
 	GeneratorVersion string
+	Profile          string // profile merged onto the specs, empty for none
 }
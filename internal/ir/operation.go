@@ -27,6 +27,10 @@ type DataOperationMeta struct {
 	Paginated  bool `json:"paginated,omitempty"`
 	SoftDelete bool `json:"soft_delete,omitempty"`
 
+	// list query: the fields results can be sorted and filtered by
+	Sorting   []string `json:"sorting,omitempty"`
+	Filtering []string `json:"filtering,omitempty"`
+
 	// semantics
 	ReturnsEntity bool `json:"returns_entity,omitempty"`
 	ReturnsList   bool `json:"returns_list,omitempty"`
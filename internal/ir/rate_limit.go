@@ -35,4 +35,13 @@ type IRRateLimit struct {
 	Custom     bool                 `json:"custom,omitempty"`
 }
 
+// StatusCode is the status a limited request is answered with, 429 unless
+// the rate limit's response sets its own.
+func (rl IRRateLimit) StatusCode() int {
+	if rl.Response != nil && rl.Response.StatusCode != 0 {
+		return rl.Response.StatusCode
+	}
+	return 429
+}
+
 type IRRateLimits map[string]IRRateLimit
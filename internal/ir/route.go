@@ -1,5 +1,7 @@
 package ir
 
+import "slices"
+
 type PathSegmentKind string
 
 const (
@@ -42,6 +44,23 @@ type IRRoute struct {
 	ResourcePolicies []string `json:"resource_policies,omitempty"`
 }
 
+// RateLimits lists the names of the rate limits r may apply, its base rate
+// limits first and then those of its policies, each once.
+func (r IRRoute) RateLimits() []string {
+	var names []string
+	for _, name := range r.BaseRateLimits {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, p := range r.PolicyRateLimits {
+		if !slices.Contains(names, p.Rate) {
+			names = append(names, p.Rate)
+		}
+	}
+	return names
+}
+
 type IRPolicyRateLimit struct {
 	Policy string `json:"policy"`     // policy name
 	Rate   string `json:"rate_limit"` // rate limit name
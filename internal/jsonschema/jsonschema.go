@@ -0,0 +1,214 @@
+// Package jsonschema describes IR models as JSON Schema 2020-12, the
+// dialect of OpenAPI 3.1, for documents and request validation alike.
+package jsonschema
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/kwizyHQ/irex/internal/ir"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Schema is a JSON Schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              json.RawMessage    `json:"default,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+}
+
+// Mode selects which side of the API a schema describes.
+type Mode int
+
+const (
+	// Response is a model as returned: private fields are left out.
+	Response Mode = iota
+	// Create is the body accepted on create.
+	Create
+	// Update is the body accepted on update: nothing is required and there
+	// are no defaults, which validators would otherwise fill in.
+	Update
+)
+
+// Model returns the schema of m in mode. Internal fields are never part of
+// it, and private ones are only accepted. Request bodies reject properties
+// they do not list; responses gain the Generated properties.
+func Model(m ir.IRModel, mode Mode) *Schema {
+	s := Fields(m.Fields, mode)
+	if mode != Response {
+		return s
+	}
+	if m.Config != nil {
+		s.Description = m.Config.Description
+	}
+	for _, p := range Generated(m) {
+		s.Properties[p.Name] = p.Schema
+		s.Required = append(s.Required, p.Name)
+	}
+	return s
+}
+
+// Property is a named property of an object schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Generated returns the properties the database sets on every document of
+// m, in order: _id, and createdAt and updatedAt when the model has
+// timestamps. Responses always carry them.
+func Generated(m ir.IRModel) []Property {
+	props := []Property{{Name: "_id", Schema: &Schema{Type: "string", ReadOnly: true}}}
+	if m.Config != nil && m.Config.Timestamps {
+		for _, name := range []string{"createdAt", "updatedAt"} {
+			props = append(props, Property{Name: name, Schema: &Schema{Type: "string", Format: "date-time", ReadOnly: true}})
+		}
+	}
+	return props
+}
+
+// Fields returns the object schema of fields in mode.
+func Fields(fields []ir.IRModelField, mode Mode) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	if mode != Response {
+		closed := false
+		s.AdditionalProperties = &closed
+	}
+	for _, f := range fields {
+		switch f.Visibility {
+		case "internal":
+			continue
+		case "private":
+			if mode == Response {
+				continue
+			}
+		}
+		fs := field(f, mode)
+		if f.Visibility == "private" {
+			fs.WriteOnly = true
+		}
+		s.Properties[f.Name] = fs
+		if f.Required && mode != Update {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return s
+}
+
+func field(f ir.IRModelField, mode Mode) *Schema {
+	var s *Schema
+	if len(f.Fields) > 0 {
+		s = Fields(f.Fields, mode)
+	} else {
+		s = Type(f.Type)
+	}
+	s.Description = f.Description
+	target := s
+	if s.Type == "array" && s.Items != nil {
+		target = s.Items
+	}
+	target.MinLength, target.MaxLength = f.MinLength, f.MaxLength
+	target.Minimum, target.Maximum = f.Min, f.Max
+	if f.Match != "" {
+		target.Pattern = f.Match
+	}
+	if mode != Update && !f.Default.IsNull() && f.Default.IsWhollyKnown() {
+		if raw, err := ctyjson.Marshal(f.Default, f.Default.Type()); err == nil {
+			s.Default = raw
+		}
+	}
+	return s
+}
+
+// Type maps a model field type to JSON Schema; unknown types accept any
+// value.
+func Type(t string) *Schema {
+	switch t {
+	case "string", "enum":
+		return &Schema{Type: "string"}
+	case "int", "integer":
+		return &Schema{Type: "integer"}
+	case "float", "number":
+		return &Schema{Type: "number"}
+	case "bool", "boolean":
+		return &Schema{Type: "boolean"}
+	case "date":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "objectId":
+		return &Schema{Type: "string", Pattern: "^[0-9a-fA-F]{24}$"}
+	case "string[]":
+		return &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	case "array":
+		return &Schema{Type: "array", Items: &Schema{}}
+	case "object":
+		return &Schema{Type: "object"}
+	}
+	return &Schema{}
+}
+
+// ListQuery returns the query string of a list operation on m: page and
+// limit when it is paginated, sort over its sorting fields, descending with
+// a leading -, and one equality filter per filtering field, typed after the
+// model field of that name. Other parameters are not accepted.
+func ListQuery(m ir.IRModel, data ir.DataOperationMeta) *Schema {
+	closed := false
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: &closed}
+	if data.Paginated {
+		one := 1
+		s.Properties["page"] = &Schema{Type: "integer", Description: "Page number, starting at 1.", Minimum: &one, Default: json.RawMessage("1")}
+		s.Properties["limit"] = &Schema{Type: "integer", Description: "Items per page.", Minimum: &one, Default: json.RawMessage("20")}
+	}
+	if len(data.Sorting) > 0 {
+		sort := &Schema{Type: "string", Description: "Field to sort by, prefixed with - for descending order."}
+		for _, f := range data.Sorting {
+			sort.Enum = append(sort.Enum, f, "-"+f)
+		}
+		s.Properties["sort"] = sort
+	}
+	for _, name := range data.Filtering {
+		if _, taken := s.Properties[name]; taken {
+			continue
+		}
+		filter := &Schema{Type: "string"}
+		for _, f := range m.Fields {
+			if t := Type(f.Type); f.Name == name && len(f.Fields) == 0 && t.Type != "object" && t.Type != "array" && t.Type != "" {
+				filter = t
+			}
+		}
+		filter.Description = "Only return items whose " + name + " equals this value."
+		s.Properties[name] = filter
+	}
+	return s
+}
+
+// QueryOrder lists the parameters of ListQuery(m, data) in the order they
+// are declared.
+func QueryOrder(data ir.DataOperationMeta) []string {
+	var names []string
+	if data.Paginated {
+		names = append(names, "page", "limit")
+	}
+	if len(data.Sorting) > 0 {
+		names = append(names, "sort")
+	}
+	for _, name := range data.Filtering {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -34,6 +34,27 @@ type RenderedTemplate struct {
 	Name       string
 	OutputPath string
 	Content    []byte
+
+	// Type, DataKey and Item record where the file came from: the template
+	// set, the data key of the provider, and for per-item templates the name
+	// of the item (e.g. the model) it was rendered for.
+	Type    TemplateType
+	DataKey string
+	Item    string
+}
+
+type Cardinality int
+
+const (
+	Single Cardinality = iota + 1
+	Many
+)
+
+// DataProvider supplies the data of the templates whose data attribute is
+// DataKey: one value for Single, or a []any rendered once per item for Many.
+type DataProvider interface {
+	DataKey() string
+	Resolve(ctx *PlanContext) (any, Cardinality)
 }
 
 type RenderSession struct {
@@ -50,6 +71,12 @@ type PlanContext struct {
 	RenderSession     *RenderSession
 	TmpDir            *tempdir.TempDir
 	WatchRegistry     *WatchRegistry
+
+	// Providers and TemplateFuncs extend every render step: each provider
+	// feeds the templates whose data key it names, and the funcs are
+	// available to all templates, replacing engine funcs of the same name.
+	Providers     []DataProvider
+	TemplateFuncs template.FuncMap
 }
 
 type Plan struct {
@@ -65,7 +65,7 @@ func (s *CompileTemplatesStep) Run(ctx *plan.PlanContext) error {
 	// Get the absolute directory of the HCL to resolve relative template files
 	baseDir, _ := filepath.Abs(filepath.Dir(finalHclPath))
 
-	root, err := s.parseTemplates(baseDir, res.Templates)
+	root, err := s.parseTemplates(ctx, baseDir, res.Templates)
 	ctx.CompiledTemplates[s.FrameworkType] = plan.TemplateBundle{
 		Templates: res.Templates,
 		Root:      root,
@@ -92,17 +92,20 @@ func baseTemplateFuncs() template.FuncMap {
 	}
 }
 
-func (s *CompileTemplatesStep) mergeFuncs() template.FuncMap {
+func (s *CompileTemplatesStep) mergeFuncs(ctx *plan.PlanContext) template.FuncMap {
 	funcs := baseTemplateFuncs()
 	for k, v := range s.TemplateFuncs {
 		funcs[k] = v
 	}
+	for k, v := range ctx.TemplateFuncs {
+		funcs[k] = v
+	}
 	return funcs
 }
 
 // ---------------- Helper Functions ----------------
-func (s *CompileTemplatesStep) parseTemplates(dir string, templates []pipeline.TemplateInfo) (*template.Template, error) {
-	tmpl := template.New("root").Funcs(s.mergeFuncs())
+func (s *CompileTemplatesStep) parseTemplates(ctx *plan.PlanContext, dir string, templates []pipeline.TemplateInfo) (*template.Template, error) {
+	tmpl := template.New("root").Funcs(s.mergeFuncs(ctx))
 
 	// recursively parse .tpl files from dir
 	var recursiveParseFunc func(string) error
@@ -30,9 +30,16 @@ func (s *FlushRendersStep) Run(ctx *plan.PlanContext) error {
 	if s.DestDir == "" {
 		s.DestDir = ctx.TmpDir.Path()
 	}
+	// the output directory is absolute; mirror its place in the project
+	// under DestDir
+	paths := ctx.IR.Config.Paths
+	output, err := filepath.Rel(paths.Root, paths.Output)
+	if err != nil {
+		return fmt.Errorf("output directory %s is outside the project root %s", paths.Output, paths.Root)
+	}
 	for _, render := range ctx.RenderSession.Files {
 		slog.Debug("Writing rendered file", "path", render.OutputPath)
-		fullPath := filepath.Join(s.DestDir, ctx.IR.Config.Paths.Output, render.OutputPath)
+		fullPath := filepath.Join(s.DestDir, output, render.OutputPath)
 		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
 			return fmt.Errorf("failed to create directories for %s: %w", fullPath, err)
 		}
@@ -5,11 +5,16 @@ import (
 	"path/filepath"
 
 	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/resolve"
 	"github.com/kwizyHQ/irex/internal/plan"
 )
 
 type LoadIR struct {
 	IRPath string
+	// Vars overrides the defaults of the config's variables block.
+	Vars resolve.VarOptions
+	// Profile selects the spec profile; empty falls back to IREX_PROFILE.
+	Profile string
 }
 
 func (s *LoadIR) ID() string {
@@ -27,6 +32,8 @@ func (s *LoadIR) Description() string {
 func (s *LoadIR) Run(ctx *plan.PlanContext) error {
 	irBundle, err := pipeline.Build(pipeline.BuildOptions{
 		ConfigPath: filepath.Join(ctx.TargetDir, s.IRPath),
+		Vars:       s.Vars,
+		Profile:    s.Profile,
 	})
 	if err.Error() != "no diagnostics" {
 		slog.Error(err.Error())
@@ -27,6 +27,14 @@ func (s *PlanSelectorStep) Description() string {
 }
 
 func (s *PlanSelectorStep) Run(ctx *plan.PlanContext) error {
+	if selectedPlan := s.Select(ctx); selectedPlan != nil {
+		return selectedPlan.Execute(ctx)
+	}
+	return nil
+}
+
+// Select returns the plan for the step's key, or nil when the map has none.
+func (s *PlanSelectorStep) Select(ctx *plan.PlanContext) *plan.Plan {
 	// Example selection logic; in practice, this would be more complex
 	var planKey string
 	if s.Key != "" {
@@ -35,10 +43,8 @@ func (s *PlanSelectorStep) Run(ctx *plan.PlanContext) error {
 		planKey = s.DeferLoadingKey(ctx)
 	}
 	if planFunc, exists := s.PlansMap[planKey]; exists {
-		selectedPlan := planFunc(ctx)
-		return selectedPlan.Execute(ctx)
-	} else {
-		slog.Error("No plan found for key: " + planKey)
+		return planFunc(ctx)
 	}
+	slog.Error("No plan found for key: " + planKey)
 	return nil
 }
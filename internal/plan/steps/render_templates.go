@@ -4,25 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"log/slog"
+	"reflect"
+	"slices"
 
 	"github.com/kwizyHQ/irex/internal/plan"
 )
 
-type Cardinality int
-
-const (
-	Single Cardinality = iota + 1
-	Many
-)
-
-type DataProvider interface {
-	DataKey() string
-	Resolve(ctx *plan.PlanContext) (any, Cardinality)
-}
-
 type RenderTemplatesStep struct {
 	TemplateType plan.TemplateType
-	Providers    []DataProvider
+	Providers    []plan.DataProvider
 }
 
 func (s *RenderTemplatesStep) ID() string {
@@ -49,11 +39,13 @@ func (s *RenderTemplatesStep) Run(ctx *plan.PlanContext) error {
 		return nil
 	}
 
-	for _, provider := range s.Providers {
+	// the caller's providers feed their own templates alongside the engine's
+	providers := append(slices.Clone(s.Providers), ctx.Providers...)
+	for _, provider := range providers {
 		data, card := provider.Resolve(ctx)
 		dataKey := provider.DataKey()
 		switch card {
-		case Many:
+		case plan.Many:
 			for _, cT := range bundle.Templates {
 				if cT.Data == dataKey {
 					slog.Debug("Executing template: " + cT.Name)
@@ -63,12 +55,13 @@ func (s *RenderTemplatesStep) Run(ctx *plan.PlanContext) error {
 						if err != nil {
 							return err
 						} else {
+							rt.Type, rt.DataKey, rt.Item = s.TemplateType, dataKey, itemName(item)
 							ctx.RenderSession.Files = append(ctx.RenderSession.Files, rt)
 						}
 					}
 				}
 			}
-		case Single:
+		case plan.Single:
 			for _, cT := range bundle.Templates {
 				if cT.Data == dataKey {
 					slog.Debug("Executing template: " + cT.Name)
@@ -76,6 +69,7 @@ func (s *RenderTemplatesStep) Run(ctx *plan.PlanContext) error {
 					if err != nil {
 						return err
 					} else {
+						rt.Type, rt.DataKey = s.TemplateType, dataKey
 						ctx.RenderSession.Files = append(ctx.RenderSession.Files, rt)
 					}
 				}
@@ -86,21 +80,74 @@ func (s *RenderTemplatesStep) Run(ctx *plan.PlanContext) error {
 	return nil
 }
 
+// OutputPaths evaluates the output pattern of every per-item template of the
+// step for each item its providers supply, without rendering the templates
+// themselves. Paths are keyed by item name.
+func (s *RenderTemplatesStep) OutputPaths(ctx *plan.PlanContext) (map[string][]string, error) {
+	out := map[string][]string{}
+	bundle, ok := ctx.CompiledTemplates[s.TemplateType]
+	if !ok {
+		return out, nil
+	}
+	providers := append(slices.Clone(s.Providers), ctx.Providers...)
+	for _, provider := range providers {
+		data, card := provider.Resolve(ctx)
+		if card != plan.Many {
+			continue
+		}
+		for _, cT := range bundle.Templates {
+			if cT.Data != provider.DataKey() {
+				continue
+			}
+			for _, item := range data.([]any) {
+				path, err := outputPath(bundle, cT, item)
+				if err != nil {
+					return nil, err
+				}
+				name := itemName(item)
+				out[name] = append(out[name], path)
+			}
+		}
+	}
+	return out, nil
+}
+
+// itemName returns the Name field of a per-item template value, which is how
+// data layers identify the model or service an item was built from.
+func itemName(item any) string {
+	v := reflect.Indirect(reflect.ValueOf(item))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if f := v.FieldByName("Name"); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
 func renderTemplate(bundle plan.TemplateBundle, t plan.TemplateDefinition, templateData any) (plan.RenderedTemplate, error) {
 	var templateBuf bytes.Buffer
-	var outputPathBuf bytes.Buffer
 	err := bundle.Root.ExecuteTemplate(&templateBuf, t.Name, templateData)
 	if err != nil {
 		return plan.RenderedTemplate{}, err
 	}
-	err = bundle.Root.ExecuteTemplate(&outputPathBuf, "output_path:"+t.Name, templateData)
+	output, err := outputPath(bundle, t, templateData)
 	if err != nil {
 		return plan.RenderedTemplate{}, err
 	}
 	renderedTemplate := plan.RenderedTemplate{
 		Name:       t.Name,
-		OutputPath: outputPathBuf.String(),
+		OutputPath: output,
 		Content:    templateBuf.Bytes(),
 	}
 	return renderedTemplate, nil
 }
+
+// outputPath evaluates the output pattern of t for templateData.
+func outputPath(bundle plan.TemplateBundle, t plan.TemplateDefinition, templateData any) (string, error) {
+	var buf bytes.Buffer
+	if err := bundle.Root.ExecuteTemplate(&buf, "output_path:"+t.Name, templateData); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
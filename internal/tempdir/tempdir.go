@@ -21,15 +21,33 @@ var (
 // Get returns the singleton TempDir instance, creating it if necessary.
 func Get() *TempDir {
 	once.Do(func() {
-		dir, err := os.MkdirTemp("", "irex_temp_*")
+		dir, err := New()
 		if err != nil {
 			panic(err)
 		}
-		singleton = &TempDir{path: dir}
+		singleton = dir
 	})
 	return singleton
 }
 
+// New creates a TempDir apart from the singleton, for a caller that
+// deletes it when done.
+func New() (*TempDir, error) {
+	dir, err := os.MkdirTemp("", "irex_temp_*")
+	if err != nil {
+		return nil, err
+	}
+	return &TempDir{path: dir}, nil
+}
+
+// Cleanup deletes the singleton's directory if Get created it.
+func Cleanup() error {
+	if singleton == nil {
+		return nil
+	}
+	return singleton.Delete()
+}
+
 // Path returns the path to the temp directory.
 func (t *TempDir) Path() string {
 	return t.path
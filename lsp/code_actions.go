@@ -0,0 +1,358 @@
+package lsp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/semantic"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const codeActionQuickFix = "quickfix"
+
+// codeActions returns quick fixes for the irex diagnostics in params. Every
+// fix is computed by editing the document with hclwrite and diffing the result.
+func (h *Handler) codeActions(params CodeActionParams) []CodeAction {
+	uri := params.TextDocument.URI
+	text, ok := h.documentText(uri)
+	if !ok {
+		return []CodeAction{}
+	}
+
+	actions := make([]CodeAction, 0)
+	add := func(d Diagnostic, title string, preferred bool, fn func(f *hclwrite.File) bool) {
+		edit := rewriteDocument(uri, text, fn)
+		if edit == nil {
+			return
+		}
+		actions = append(actions, CodeAction{
+			Title:       title,
+			Kind:        codeActionQuickFix,
+			Diagnostics: []Diagnostic{d},
+			IsPreferred: preferred,
+			Edit:        edit,
+		})
+	}
+
+	for _, d := range params.Context.Diagnostics {
+		code, _ := d.Code.(string)
+		if d.Data == nil || d.Data.HclPath == "" {
+			continue
+		}
+		path := d.Data.HclPath
+
+		switch code {
+		case diagnostics.CodeInputRequired:
+			switch path {
+			case "policies", "rate_limits", "services":
+				add(d, "Add '"+path+"' block", true, func(f *hclwrite.File) bool {
+					appendTopLevelBlock(f, path)
+					return true
+				})
+			}
+
+		case diagnostics.CodeInputRecommended:
+			if strings.HasPrefix(path, "policies.policy.") && strings.HasSuffix(path, ".scope") {
+				blockPath := strings.TrimSuffix(path, ".scope")
+				for i, scope := range []string{"request", "resource"} {
+					add(d, "Set scope = \""+scope+"\"", i == 0, func(f *hclwrite.File) bool {
+						blocks := findBlocks(f.Body(), "", blockPath)
+						if len(blocks) == 0 {
+							return false
+						}
+						blocks[len(blocks)-1].Body().SetAttributeValue("scope", cty.StringVal(scope))
+						return true
+					})
+				}
+			}
+
+		case diagnostics.CodeInputDuplicate:
+			add(d, "Rename duplicate", true, func(f *hclwrite.File) bool {
+				return renameDuplicate(f, path)
+			})
+
+		case diagnostics.CodeServiceModelNotFound:
+			current := attributeString(text, uri, path)
+			names := make([]string, 0)
+			if cfg := h.currentConfig(); cfg != "" {
+				for _, m := range pipeline.LoadModels(cfg, h.fs) {
+					names = append(names, m.Name)
+				}
+			}
+			if best := semantic.ClosestName(current, names); best != "" {
+				add(d, "Change model to '"+best+"'", true, func(f *hclwrite.File) bool {
+					blockPath, attr := splitAttrPath(path)
+					blocks := findBlocks(f.Body(), "", blockPath)
+					if len(blocks) == 0 {
+						return false
+					}
+					blocks[len(blocks)-1].Body().SetAttributeValue(attr, cty.StringVal(best))
+					return true
+				})
+			}
+
+		case diagnostics.CodeServicePolicyNotFound:
+			name := applyTargetName(text, uri, path)
+			if name == "" {
+				continue
+			}
+			add(d, "Create custom policy '"+name+"'", false, func(f *hclwrite.File) bool {
+				return appendCustom(f, "policies", name)
+			})
+
+		case diagnostics.CodeServiceRateLimitNotFound:
+			var missing []string
+			if strings.HasSuffix(path, ".rate_limits") {
+				known := definedRateLimits(text, uri)
+				for _, n := range attributeStrings(text, uri, path) {
+					if _, ok := known[n]; !ok {
+						missing = append(missing, n)
+					}
+				}
+			} else if name := applyTargetName(text, uri, path); name != "" {
+				missing = append(missing, name)
+			}
+			for _, name := range missing {
+				add(d, "Create custom rate limit '"+name+"'", false, func(f *hclwrite.File) bool {
+					return appendCustom(f, "rate_limits", name)
+				})
+			}
+		}
+	}
+	return actions
+}
+
+// documentText returns the open buffer for uri, or the file contents on disk.
+func (h *Handler) documentText(uri string) (string, bool) {
+	h.mu.Lock()
+	text, ok := h.docs[uri]
+	h.mu.Unlock()
+	if ok {
+		return text, true
+	}
+	path, err := UriToPath(uri)
+	if err != nil {
+		return "", false
+	}
+	src, err := h.fs.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(src), true
+}
+
+func (h *Handler) currentConfig() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.configPath
+}
+
+// rewriteDocument parses text with hclwrite, lets fn modify it and returns
+// the resulting WorkspaceEdit, or nil when fn made no change.
+func rewriteDocument(uri, text string, fn func(f *hclwrite.File) bool) *WorkspaceEdit {
+	filename, _ := UriToPath(uri)
+	f, diags := hclwrite.ParseConfig([]byte(text), filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	if !fn(f) {
+		return nil
+	}
+	return documentEdit(uri, text, string(f.Bytes()))
+}
+
+// findBlocks returns every block whose walker path (see pipeline.WalkHCLSymbols)
+// equals target, in document order.
+func findBlocks(body *hclwrite.Body, prefix, target string) []*hclwrite.Block {
+	var out []*hclwrite.Block
+	for _, b := range body.Blocks() {
+		path := b.Type()
+		if prefix != "" {
+			path = prefix + "." + b.Type()
+		}
+		for _, l := range b.Labels() {
+			path += "." + l
+		}
+		if path == target {
+			out = append(out, b)
+		}
+		if strings.HasPrefix(target, path+".") {
+			out = append(out, findBlocks(b.Body(), path, target)...)
+		}
+	}
+	return out
+}
+
+func appendTopLevelBlock(f *hclwrite.File, name string) *hclwrite.Block {
+	body := f.Body()
+	if src := f.Bytes(); len(src) > 0 && src[len(src)-1] != '\n' {
+		body.AppendNewline()
+	}
+	if len(body.Blocks()) > 0 || len(body.Attributes()) > 0 {
+		body.AppendNewline()
+	}
+	return body.AppendNewBlock(name, nil)
+}
+
+// appendCustom adds `custom "name" {}` to the top-level block parent,
+// creating parent when the document has none.
+func appendCustom(f *hclwrite.File, parent, name string) bool {
+	blocks := findBlocks(f.Body(), "", parent)
+	var block *hclwrite.Block
+	if len(blocks) > 0 {
+		block = blocks[0]
+	} else {
+		block = appendTopLevelBlock(f, parent)
+	}
+	if len(findBlocks(block.Body(), parent, parent+".custom."+name)) > 0 {
+		return false
+	}
+	block.Body().AppendNewBlock("custom", []string{name})
+	return true
+}
+
+// renameDuplicate gives the last block at path a unique `_N` suffixed label.
+func renameDuplicate(f *hclwrite.File, path string) bool {
+	blocks := findBlocks(f.Body(), "", path)
+	if len(blocks) < 2 {
+		return false
+	}
+	target := blocks[len(blocks)-1]
+	labels := target.Labels()
+	if len(labels) == 0 {
+		return false
+	}
+	base := labels[len(labels)-1]
+	prefix := strings.TrimSuffix(path, base)
+	for n := 2; ; n++ {
+		candidate := base + "_" + strconv.Itoa(n)
+		if len(findBlocks(f.Body(), "", prefix+candidate)) == 0 {
+			labels[len(labels)-1] = candidate
+			target.SetLabels(labels)
+			return true
+		}
+	}
+}
+
+func splitAttrPath(path string) (blockPath, attr string) {
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// syntaxBlocks is findBlocks for the hclsyntax AST, used where values need evaluating.
+func syntaxBlocks(body *hclsyntax.Body, prefix, target string) []*hclsyntax.Block {
+	var out []*hclsyntax.Block
+	for _, b := range body.Blocks {
+		path := b.Type
+		if prefix != "" {
+			path = prefix + "." + b.Type
+		}
+		for _, l := range b.Labels {
+			path += "." + l
+		}
+		if path == target {
+			out = append(out, b)
+		}
+		if strings.HasPrefix(target, path+".") {
+			out = append(out, syntaxBlocks(b.Body, path, target)...)
+		}
+	}
+	return out
+}
+
+func parseSyntax(text, uri string) *hclsyntax.Body {
+	filename, _ := UriToPath(uri)
+	f, _ := hclsyntax.ParseConfig([]byte(text), filename, hcl.InitialPos)
+	if f == nil {
+		return nil
+	}
+	body, _ := f.Body.(*hclsyntax.Body)
+	return body
+}
+
+// attributeValue evaluates the literal attribute at path (block path + "." + name).
+func attributeValue(text, uri, path string) cty.Value {
+	body := parseSyntax(text, uri)
+	if body == nil {
+		return cty.NilVal
+	}
+	blockPath, attr := splitAttrPath(path)
+	attrs := body.Attributes
+	if blockPath != "" {
+		blocks := syntaxBlocks(body, "", blockPath)
+		if len(blocks) == 0 {
+			return cty.NilVal
+		}
+		attrs = blocks[len(blocks)-1].Body.Attributes
+	}
+	a, ok := attrs[attr]
+	if !ok {
+		return cty.NilVal
+	}
+	v, diags := a.Expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal
+	}
+	return v
+}
+
+func attributeString(text, uri, path string) string {
+	v := attributeValue(text, uri, path)
+	if v == cty.NilVal || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return ""
+	}
+	return v.AsString()
+}
+
+func attributeStrings(text, uri, path string) []string {
+	v := attributeValue(text, uri, path)
+	if v == cty.NilVal || v.IsNull() || !v.IsKnown() || !v.CanIterateElements() {
+		return nil
+	}
+	var out []string
+	for it := v.ElementIterator(); it.Next(); {
+		_, e := it.Element()
+		if e.IsKnown() && !e.IsNull() && e.Type() == cty.String {
+			out = append(out, e.AsString())
+		}
+	}
+	return out
+}
+
+// applyTargetName returns the second label of the apply block at path.
+func applyTargetName(text, uri, path string) string {
+	body := parseSyntax(text, uri)
+	if body == nil {
+		return ""
+	}
+	blocks := syntaxBlocks(body, "", path)
+	if len(blocks) == 0 || len(blocks[0].Labels) < 2 {
+		return ""
+	}
+	return blocks[0].Labels[1]
+}
+
+// definedRateLimits collects the preset and custom rate limit names in the document.
+func definedRateLimits(text, uri string) map[string]struct{} {
+	out := map[string]struct{}{}
+	body := parseSyntax(text, uri)
+	if body == nil {
+		return out
+	}
+	for _, rl := range syntaxBlocks(body, "", "rate_limits") {
+		for _, b := range rl.Body.Blocks {
+			if (b.Type == "preset" || b.Type == "custom") && len(b.Labels) > 0 {
+				out[b.Labels[0]] = struct{}{}
+			}
+		}
+	}
+	return out
+}
@@ -4,14 +4,20 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// Run returns a cobra subcommand that runs the language server over stdio.
+// Run returns a cobra subcommand that runs the language server over stdio,
+// or over TCP when --listen is given.
 func Run() *cobra.Command {
+	var listen string
 	cmd := &cobra.Command{
 		Use:   "lsp",
 		Short: "Run language server over stdio",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if listen != "" {
+				return ListenAndServe(cmd.Context(), listen)
+			}
 			return RunServer(cmd.Context())
 		},
 	}
+	cmd.Flags().StringVar(&listen, "listen", "", "serve over TCP on host:port instead of stdio (e.g. 127.0.0.1:7777)")
 	return cmd
 }
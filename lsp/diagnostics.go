@@ -8,16 +8,21 @@ import (
 	"strings"
 
 	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
-// simple heuristic: report a diagnostic for any line containing "TODO" or "error"
-func computeDiagnostics(text string, uri string) []Diagnostic {
-	var out = make([]Diagnostic, 0)
+// computeDiagnostics validates a single document in isolation.
+func computeDiagnostics(text string, uri string, fc pipeline.FileContext) []Diagnostic {
 	filename, _ := UriToPath(uri)
-	diags := pipeline.GetDiagnosticsForFile(filename, text)
+	return toLSPDiagnostics(pipeline.GetDiagnosticsForFile(filename, text, fc))
+}
+
+// toLSPDiagnostics converts pipeline diagnostics (already in editor ranges) to LSP diagnostics.
+func toLSPDiagnostics(diags diagnostics.Diagnostics) []Diagnostic {
+	var out = make([]Diagnostic, 0, len(diags))
 	for _, d := range diags {
-		out = append(out, Diagnostic{
+		diag := Diagnostic{
 			Range: Range{
 				Start: Position{Line: d.Range.Start.Line, Character: d.Range.Start.Column},
 				End:   Position{Line: d.Range.End.Line, Character: d.Range.End.Column},
@@ -26,11 +31,35 @@ func computeDiagnostics(text string, uri string) []Diagnostic {
 			Source:   "irex-lsp",
 			Message:  d.Message,
 			Code:     d.Code,
-		})
+			Data:     diagnosticData(d),
+		}
+		if href := diagnostics.CodeURL(d.Code); href != "" {
+			diag.CodeDescription = &CodeDescription{Href: href}
+		}
+		for _, r := range d.Related {
+			if r.Filename == "" {
+				continue
+			}
+			diag.RelatedInformation = append(diag.RelatedInformation, DiagnosticRelatedInformation{
+				Location: Location{URI: PathToUri(r.Filename), Range: Range{
+					Start: Position{Line: r.Range.Start.Line, Character: r.Range.Start.Column},
+					End:   Position{Line: r.Range.End.Line, Character: r.Range.End.Column},
+				}},
+				Message: r.Message,
+			})
+		}
+		out = append(out, diag)
 	}
 	return out
 }
 
+func diagnosticData(d diagnostics.Diagnostic) *DiagnosticData {
+	if d.HclPath == "" {
+		return nil
+	}
+	return &DiagnosticData{HclPath: d.HclPath}
+}
+
 func publishDiagnostics(ctx context.Context, conn *jsonrpc2.Conn, uri string, diags []Diagnostic) error {
 	params := PublishDiagnosticsParams{URI: uri, Diagnostics: diags}
 	return conn.Notify(ctx, "textDocument/publishDiagnostics", params)
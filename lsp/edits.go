@@ -0,0 +1,132 @@
+package lsp
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// minimalEdit returns a single TextEdit turning oldText into newText by
+// replacing only the span between their common prefix and suffix.
+// ok is false when the texts are identical.
+func minimalEdit(oldText, newText string) (edit TextEdit, ok bool) {
+	if oldText == newText {
+		return TextEdit{}, false
+	}
+	prefix := 0
+	for prefix < len(oldText) && prefix < len(newText) && oldText[prefix] == newText[prefix] {
+		prefix++
+	}
+	// never split a multi-byte rune
+	for prefix > 0 && prefix < len(oldText) && !utf8.RuneStart(oldText[prefix]) {
+		prefix--
+	}
+	suffix := 0
+	for suffix < len(oldText)-prefix && suffix < len(newText)-prefix &&
+		oldText[len(oldText)-1-suffix] == newText[len(newText)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(oldText[len(oldText)-suffix]) {
+		suffix--
+	}
+	return TextEdit{
+		Range: Range{
+			Start: offsetToPosition(oldText, prefix),
+			End:   offsetToPosition(oldText, len(oldText)-suffix),
+		},
+		NewText: newText[prefix : len(newText)-suffix],
+	}, true
+}
+
+// offsetToPosition converts a byte offset into an LSP position, counting
+// characters in UTF-16 code units as the protocol requires.
+func offsetToPosition(text string, offset int) Position {
+	line, lineStart := 0, 0
+	for i := 0; i < offset && i < len(text); i++ {
+		if text[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+	char := 0
+	for _, r := range text[lineStart:min(offset, len(text))] {
+		char += len(utf16.Encode([]rune{r}))
+	}
+	return Position{Line: line, Character: char}
+}
+
+// documentEdit wraps a rewrite of uri from oldText to newText as a WorkspaceEdit.
+func documentEdit(uri, oldText, newText string) *WorkspaceEdit {
+	edit, ok := minimalEdit(oldText, newText)
+	if !ok {
+		return nil
+	}
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{uri: {edit}}}
+}
+
+// lineEdits returns the edits turning oldText into newText as whole-line
+// replacements. Unchanged leading and trailing lines are skipped; when the
+// changed region keeps its line count (the usual case for formatting) each
+// run of changed lines becomes its own edit, so range formatting can keep
+// only the edits it was asked for.
+func lineEdits(oldText, newText string) []TextEdit {
+	if oldText == newText {
+		return []TextEdit{}
+	}
+	oldLines := strings.SplitAfter(oldText, "\n")
+	newLines := strings.SplitAfter(newText, "\n")
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	oldMid := oldLines[prefix : len(oldLines)-suffix]
+	newMid := newLines[prefix : len(newLines)-suffix]
+
+	lineEdit := func(start, end int, text string) TextEdit {
+		return TextEdit{
+			Range:   Range{Start: linePosition(oldLines, start), End: linePosition(oldLines, end)},
+			NewText: text,
+		}
+	}
+	if len(oldMid) != len(newMid) {
+		return []TextEdit{lineEdit(prefix, prefix+len(oldMid), strings.Join(newMid, ""))}
+	}
+
+	edits := make([]TextEdit, 0)
+	for i := 0; i < len(oldMid); {
+		if oldMid[i] == newMid[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < len(oldMid) && oldMid[j] != newMid[j] {
+			j++
+		}
+		edits = append(edits, lineEdit(prefix+i, prefix+j, strings.Join(newMid[i:j], "")))
+		i = j
+	}
+	return edits
+}
+
+// linePosition is the position of the start of line n, or the end of the
+// text when n is past the last line.
+func linePosition(lines []string, n int) Position {
+	if n < len(lines) {
+		return Position{Line: n}
+	}
+	last := lines[len(lines)-1]
+	if strings.HasSuffix(last, "\n") {
+		return Position{Line: len(lines)}
+	}
+	char := 0
+	for _, r := range last {
+		char += len(utf16.Encode([]rune{r}))
+	}
+	return Position{Line: len(lines) - 1, Character: char}
+}
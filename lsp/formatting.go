@@ -0,0 +1,41 @@
+package lsp
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/format"
+)
+
+// formatDocument formats uri with the same formatter as `irex format` and
+// returns line based edits. Documents with syntax errors are left alone, as
+// the formatter would otherwise reshuffle half-typed input.
+func (h *Handler) formatDocument(uri string) []TextEdit {
+	text, ok := h.documentText(uri)
+	if !ok {
+		return []TextEdit{}
+	}
+	filename, _ := UriToPath(uri)
+	if _, diags := hclsyntax.ParseConfig([]byte(text), filename, hcl.InitialPos); diags.HasErrors() {
+		return []TextEdit{}
+	}
+	h.mu.Lock()
+	opts := format.Options{Canonical: h.canonicalFormat}
+	h.mu.Unlock()
+	return lineEdits(text, string(format.Source(filename, []byte(text), opts)))
+}
+
+// formatRange keeps only the edits of formatDocument that overlap r.
+func (h *Handler) formatRange(uri string, r Range) []TextEdit {
+	out := make([]TextEdit, 0)
+	for _, e := range h.formatDocument(uri) {
+		// line edits end at column 0 of the line after the last one they touch
+		last := e.Range.End.Line
+		if e.Range.End.Character == 0 && last > e.Range.Start.Line {
+			last--
+		}
+		if e.Range.Start.Line <= r.End.Line && last >= r.Start.Line {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/engines"
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/kwizyHQ/irex/internal/plan"
+	"github.com/kwizyHQ/irex/internal/tempdir"
+)
+
+// renderProject builds the project as the editor currently sees it and
+// renders every template in memory. It returns a nil bundle when the
+// project does not build.
+func (h *Handler) renderProject() (*ir.IRBundle, []plan.RenderedTemplate, error) {
+	configPath := h.currentConfig()
+	if configPath == "" {
+		return nil, nil, nil
+	}
+	bundle, _ := pipeline.Build(pipeline.BuildOptions{ConfigPath: configPath, FS: h.fs})
+	if bundle == nil {
+		return nil, nil, nil
+	}
+	configDir := bundle.Config.Paths.Root
+
+	if _, ok := engines.RenderPlans[bundle.Config.Runtime.Name]; !ok {
+		return bundle, nil, nil
+	}
+	ctx := &plan.PlanContext{
+		TargetDir:         configDir,
+		IR:                bundle,
+		TmpDir:            tempdir.Get(),
+		CompiledTemplates: make(plan.CompiledTemplates),
+		RenderSession:     &plan.RenderSession{},
+	}
+	if err := engines.Render(ctx); err != nil {
+		return bundle, nil, err
+	}
+	return bundle, ctx.RenderSession.Files, nil
+}
+
+const (
+	commandPreviewGenerated = "irex.previewGenerated"
+	previewScheme           = "irex-preview"
+)
+
+// previewGenerated renders the project in memory and returns the files
+// generated for one model or service. Templates rendered once per item are
+// matched by item name; when the template set has none for the service (or
+// model), the shared single files it contributes to are returned instead.
+func (h *Handler) previewGenerated(args PreviewArgs) (PreviewResult, error) {
+	var templateType plan.TemplateType
+	switch args.Kind {
+	case "model":
+		templateType = plan.TemplateTypeSchema
+	case "service":
+		templateType = plan.TemplateTypeService
+	default:
+		return PreviewResult{}, fmt.Errorf("unknown preview kind %q, expected \"model\" or \"service\"", args.Kind)
+	}
+
+	bundle, files, err := h.renderProject()
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	if bundle == nil {
+		return PreviewResult{}, fmt.Errorf("the project has errors, fix them to preview generated code")
+	}
+	switch templateType {
+	case plan.TemplateTypeSchema:
+		if _, ok := bundle.Models[args.Name]; !ok {
+			return PreviewResult{}, fmt.Errorf("model %q not found", args.Name)
+		}
+	case plan.TemplateTypeService:
+		if _, ok := bundle.Services[args.Name]; !ok {
+			return PreviewResult{}, fmt.Errorf("service %q not found", args.Name)
+		}
+	}
+
+	var perItem, shared []plan.RenderedTemplate
+	for _, f := range files {
+		if f.Type != templateType {
+			continue
+		}
+		switch f.Item {
+		case args.Name:
+			perItem = append(perItem, f)
+		case "":
+			shared = append(shared, f)
+		}
+	}
+	selected := perItem
+	if len(selected) == 0 {
+		selected = shared
+	}
+
+	result := PreviewResult{Kind: args.Kind, Name: args.Name, Files: make([]PreviewFile, 0, len(selected))}
+	base := filepath.Dir(h.currentConfig())
+	for _, f := range selected {
+		path := filepath.Join(bundle.Config.Paths.Output, f.OutputPath)
+		if rel, err := filepath.Rel(base, path); err == nil {
+			path = rel
+		}
+		path = filepath.ToSlash(path)
+		result.Files = append(result.Files, PreviewFile{
+			URI:      previewScheme + ":/" + path,
+			Path:     path,
+			Template: f.Name,
+			Content:  string(f.Content),
+		})
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].Path < result.Files[j].Path })
+	return result, nil
+}
@@ -5,39 +5,128 @@ import (
 	"encoding/json"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/kwizyHQ/irex/internal/core/overlay"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
 type Handler struct {
 	mu   sync.Mutex
 	docs map[string]string
+	// versions holds the editor's version of each open document; edits
+	// counts changes to any of them. Cached code lenses are keyed on both.
+	versions map[string]int
+	edits    uint64
+	// fs serves open documents from memory and everything else from disk,
+	// so the pipeline always sees what the user is looking at.
+	fs *overlay.Overlay
+
+	// workspace state, see workspace.go
+	root       string
+	configPath string
+	published  map[string]string // path -> uri of files holding diagnostics
+	publishMu  sync.Mutex
+
+	// debouncing of workspace diagnostics, see schedulePublish
+	scheduleMu   sync.Mutex
+	publishTimer *time.Timer
+	publishing   bool
+	publishDirty bool
+
+	canonicalFormat bool
+
+	// code lens caches, see lens.go
+	lensMu  sync.Mutex
+	lenses  map[string]lensCache
+	outputs *outputsCache
+
+	// exit ends the session; it exits the process for stdio servers
+	exit func()
 }
 
 func NewHandler() *Handler {
-	return &Handler{docs: make(map[string]string)}
+	return &Handler{
+		docs:      make(map[string]string),
+		versions:  make(map[string]int),
+		fs:        overlay.New(overlay.OS),
+		published: make(map[string]string),
+		lenses:    make(map[string]lensCache),
+		exit:      func() { os.Exit(0) },
+	}
+}
+
+// setDocument records the latest text and version for uri in both the
+// document map and the overlay.
+func (h *Handler) setDocument(uri, text string, version int) {
+	h.mu.Lock()
+	h.docs[uri] = text
+	h.versions[uri] = version
+	h.edits++
+	h.mu.Unlock()
+	if path, err := UriToPath(uri); err == nil {
+		h.fs.Set(path, []byte(text))
+	}
+}
+
+// closeDocument forgets uri so the pipeline reads the saved file again.
+func (h *Handler) closeDocument(uri string) {
+	h.mu.Lock()
+	delete(h.docs, uri)
+	delete(h.versions, uri)
+	h.edits++
+	h.mu.Unlock()
+	if path, err := UriToPath(uri); err == nil {
+		h.fs.Delete(path)
+	}
 }
 
 func (h *Handler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
 	switch req.Method {
 	case "initialize":
+		var params InitializeParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+			h.initWorkspace(params)
+			h.mu.Lock()
+			h.canonicalFormat = params.InitializationOptions.CanonicalFormat
+			h.mu.Unlock()
+		}
 		// minimal capabilities response
 		result := map[string]interface{}{
 			"capabilities": map[string]interface{}{
 				"textDocumentSync": 1,
+				"codeActionProvider": map[string]interface{}{
+					"codeActionKinds": []string{codeActionQuickFix},
+				},
+				"documentSymbolProvider":          true,
+				"workspaceSymbolProvider":         true,
+				"foldingRangeProvider":            true,
+				"documentFormattingProvider":      true,
+				"documentRangeFormattingProvider": true,
+				"codeLensProvider":                map[string]interface{}{"resolveProvider": true},
+				"inlayHintProvider":               true,
+				"semanticTokensProvider": map[string]interface{}{
+					"legend": map[string]interface{}{
+						"tokenTypes":     semanticTokenTypes,
+						"tokenModifiers": semanticTokenModifiers,
+					},
+					"full":  true,
+					"range": true,
+				},
+				"executeCommandProvider": map[string]interface{}{
+					"commands": []string{commandPreviewGenerated},
+				},
 			},
 		}
 		_ = conn.Reply(ctx, req.ID, result)
 	case "initialized":
-		// no-op
-		_ = conn.Reply(ctx, req.ID, nil)
+		// no-op; it is a notification, so there is nothing to reply to
 	case "textDocument/didOpen":
 		var params DidOpenTextDocumentParams
 		if req.Params != nil {
 			_ = json.Unmarshal(*req.Params, &params)
-			h.mu.Lock()
-			h.docs[params.TextDocument.URI] = params.TextDocument.Text
-			h.mu.Unlock()
+			h.setDocument(params.TextDocument.URI, params.TextDocument.Text, params.TextDocument.Version)
 			go h.validateAndPublish(ctx, conn, params.TextDocument.URI)
 		}
 	case "textDocument/didChange":
@@ -45,27 +134,127 @@ func (h *Handler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2
 		if req.Params != nil {
 			_ = json.Unmarshal(*req.Params, &params)
 			if len(params.ContentChanges) > 0 {
-				h.mu.Lock()
-				h.docs[params.TextDocument.URI] = params.ContentChanges[0].Text
-				h.mu.Unlock()
+				h.setDocument(params.TextDocument.URI, params.ContentChanges[0].Text, params.TextDocument.Version)
 				go h.validateAndPublish(ctx, conn, params.TextDocument.URI)
 			}
 		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+			h.closeDocument(params.TextDocument.URI)
+			h.schedulePublish(ctx, conn)
+		}
+	case "textDocument/codeAction":
+		var params CodeActionParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.codeActions(params))
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		text, _ := h.documentText(params.TextDocument.URI)
+		_ = conn.Reply(ctx, req.ID, documentSymbols(params.TextDocument.URI, text))
+	case "workspace/symbol":
+		var params WorkspaceSymbolParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.workspaceSymbols(params.Query))
+	case "textDocument/foldingRange":
+		var params FoldingRangeParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		text, _ := h.documentText(params.TextDocument.URI)
+		_ = conn.Reply(ctx, req.ID, foldingRanges(params.TextDocument.URI, text))
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.formatDocument(params.TextDocument.URI))
+	case "textDocument/rangeFormatting":
+		var params DocumentRangeFormattingParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.formatRange(params.TextDocument.URI, params.Range))
+	case "textDocument/codeLens":
+		var params CodeLensParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.codeLenses(params.TextDocument.URI))
+	case "codeLens/resolve":
+		var lens CodeLens
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &lens)
+		}
+		_ = conn.Reply(ctx, req.ID, h.resolveCodeLens(lens))
+	case "textDocument/inlayHint":
+		var params InlayHintParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.inlayHints(params.TextDocument.URI, params.Range))
+	case "textDocument/semanticTokens/full":
+		var params SemanticTokensParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.semanticTokens(params.TextDocument.URI, nil))
+	case "textDocument/semanticTokens/range":
+		var params SemanticTokensRangeParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		_ = conn.Reply(ctx, req.ID, h.semanticTokens(params.TextDocument.URI, &params.Range))
+	case "workspace/executeCommand":
+		var params ExecuteCommandParams
+		if req.Params != nil {
+			_ = json.Unmarshal(*req.Params, &params)
+		}
+		result, err := h.executeCommand(params)
+		if err != nil {
+			_ = conn.ReplyWithError(ctx, req.ID, err)
+			return
+		}
+		_ = conn.Reply(ctx, req.ID, result)
 	case "shutdown":
 		_ = conn.Reply(ctx, req.ID, nil)
 	case "exit":
-		os.Exit(0)
+		h.exit()
 	default:
-		// ignore notifications and unknown methods silently
+		// ignore notifications silently, but answer requests so clients don't wait forever
 		// slog.Debug("unknown method", "method", req.Method)
+		if !req.Notif {
+			_ = conn.ReplyWithError(ctx, req.ID, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: "method not supported: " + req.Method})
+		}
 	}
 }
 
 func (h *Handler) validateAndPublish(ctx context.Context, conn *jsonrpc2.Conn, uri string) {
-	h.mu.Lock()
-	text := h.docs[uri]
-	h.mu.Unlock()
+	h.discoverConfig(uri)
+	h.schedulePublish(ctx, conn)
+}
 
-	diags := computeDiagnostics(text, uri)
-	_ = publishDiagnostics(ctx, conn, uri, diags)
+func (h *Handler) executeCommand(params ExecuteCommandParams) (interface{}, *jsonrpc2.Error) {
+	switch params.Command {
+	case commandPreviewGenerated:
+		var args PreviewArgs
+		if len(params.Arguments) == 0 || json.Unmarshal(params.Arguments[0], &args) != nil {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: "expected {\"kind\": ..., \"name\": ...}"}
+		}
+		result, err := h.previewGenerated(args)
+		if err != nil {
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams, Message: err.Error()}
+		}
+		return result, nil
+	default:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: "unknown command " + params.Command}
+	}
 }
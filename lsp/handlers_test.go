@@ -0,0 +1,265 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+const testConfig = `project {
+  name        = "demo"
+  version     = "1.0.0"
+  author      = "me"
+  license     = "MIT"
+  paths {
+    specifications = "./spec"
+    templates      = "./spec/templates"
+    output         = "./src/generated"
+  }
+  generator {
+    schema  = true
+    service = true
+  }
+  runtime {
+    name    = "node-ts"
+    version = "18"
+    options {
+      package_manager = "npm"
+      entry           = "src/app.ts"
+    }
+    schema {
+      framework = "mongoose"
+      options {
+        uri = env("MONGO_URI")
+        db  = env("MONGO_DB")
+      }
+    }
+    service {
+      framework = "fastify"
+      options {
+        logger = true
+        port   = 8080
+        host   = "localhost"
+      }
+    }
+  }
+}
+`
+
+const testModels = `models {
+  model "User" {
+    field "name" {
+      type = "string"
+    }
+  }
+}
+`
+
+const testServices = `policies {
+  policy "auth" {
+    effect = "allow"
+    scope  = "request"
+    rule   = "ctx.auth != null"
+  }
+}
+
+rate_limits {
+  preset "standard" {
+    limit = "100/min"
+  }
+}
+
+services {
+  base_path = "/api/v1"
+  service "users" {
+    model = "User"
+    path  = "/users"
+  }
+}
+`
+
+func testProject() map[string]string {
+	return map[string]string{
+		"irex.hcl":                  testConfig,
+		"spec/schema/models.hcl":    testModels,
+		"spec/service/services.hcl": testServices,
+	}
+}
+
+func TestDiagnosticsFollowEdits(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	broken := strings.Replace(testServices, `"User"`, `"Usr"`, 1)
+	c.open("spec/service/services.hcl", broken)
+	diags := c.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return hasCode(d, "service.model.not_found")
+	})
+	for _, d := range diags {
+		if d.Code == "service.model.not_found" && d.Range.Start.Line != 17 {
+			t.Errorf("model diagnostic on line %d, want 17", d.Range.Start.Line)
+		}
+	}
+
+	c.change("spec/service/services.hcl", testServices)
+	c.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return !hasCode(d, "service.model.not_found")
+	})
+}
+
+func TestEditsCoalesceIntoFewBuilds(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	c.open("spec/service/services.hcl", testServices)
+	for i := 0; i < 20; i++ {
+		c.change("spec/service/services.hcl", strings.Replace(testServices, `"/users"`, fmt.Sprintf(`"/users%d"`, i), 1))
+	}
+	c.change("spec/service/services.hcl", strings.Replace(testServices, `"User"`, `"Usr"`, 1))
+	c.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return hasCode(d, "service.model.not_found")
+	})
+	// let any run still pending publish before counting
+	time.Sleep(3 * publishDelay)
+	if n := c.publishCount("spec/service/services.hcl"); n > 3 {
+		t.Fatalf("22 quick edits published diagnostics %d times", n)
+	}
+}
+
+func TestUnsavedSchemaReachesServices(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	// renaming the model in an unsaved buffer breaks the service on disk
+	c.open("spec/schema/models.hcl", strings.Replace(testModels, `"User"`, `"Member"`, 1))
+	c.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return hasCode(d, "service.model.not_found")
+	})
+
+	c.change("spec/schema/models.hcl", testModels)
+	c.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return !hasCode(d, "service.model.not_found")
+	})
+}
+
+func TestQuickFixForUnknownModel(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	c.open("spec/service/services.hcl", strings.Replace(testServices, `"User"`, `"Usr"`, 1))
+	diags := c.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return hasCode(d, "service.model.not_found")
+	})
+
+	var actions []CodeAction
+	if err := c.request("textDocument/codeAction", CodeActionParams{
+		TextDocument: TextDocumentIdentifier{URI: c.uri("spec/service/services.hcl")},
+		Context:      CodeActionContext{Diagnostics: diags},
+	}, &actions); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range actions {
+		if a.Title == "Change model to 'User'" {
+			return
+		}
+	}
+	t.Fatalf("no model quick fix in %+v", actions)
+}
+
+func TestFormattingReturnsEdits(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	c.open("spec/service/services.hcl", strings.Replace(testServices, `model = "User"`, `model="User"`, 1))
+	var edits []TextEdit
+	if err := c.request("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: c.uri("spec/service/services.hcl")},
+	}, &edits); err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 || edits[0].Range.Start.Line != 17 || edits[0].NewText != "    model = \"User\"\n" {
+		t.Fatalf("unexpected edits %+v", edits)
+	}
+}
+
+func TestModelLensResolvesOutputs(t *testing.T) {
+	// a lowercase model name differs from its TypeScript identifier
+	models := strings.Replace(testModels, "models {\n", "models {\n  model \"post\" {\n    field \"title\" {\n      type = \"string\"\n    }\n  }\n", 1)
+	files := testProject()
+	files["spec/schema/models.hcl"] = models
+	c := newTestClient(t, files)
+
+	c.open("spec/schema/models.hcl", models)
+	var lenses []CodeLens
+	if err := c.request("textDocument/codeLens", CodeLensParams{
+		TextDocument: TextDocumentIdentifier{URI: c.uri("spec/schema/models.hcl")},
+	}, &lenses); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"User": {"models/user.ts", "validators/user.schema.ts", "validators/user.zod.ts"},
+		"post": {"models/post.ts", "validators/post.schema.ts", "validators/post.zod.ts"},
+	}
+	for _, lens := range lenses {
+		if lens.Data == nil {
+			continue
+		}
+		if lens.Command != nil {
+			t.Fatalf("output lens resolved before codeLens/resolve: %+v", lens)
+		}
+		var resolved CodeLens
+		if err := c.request("codeLens/resolve", lens, &resolved); err != nil {
+			t.Fatal(err)
+		}
+		files, ok := want[lens.Data.Model]
+		if !ok {
+			t.Fatalf("unexpected output lens for %q", lens.Data.Model)
+		}
+		for _, file := range files {
+			if resolved.Command == nil || !strings.Contains(resolved.Command.Title, file) {
+				t.Fatalf("resolved lens for %s misses %s: %+v", lens.Data.Model, file, resolved)
+			}
+		}
+		delete(want, lens.Data.Model)
+	}
+	if len(want) > 0 {
+		t.Fatalf("no output lens for %v in %+v", want, lenses)
+	}
+}
+
+func TestUnsupportedRequestIsAnswered(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	c.open("spec/service/services.hcl", testServices)
+	err := c.request("textDocument/completion", map[string]interface{}{
+		"textDocument": TextDocumentIdentifier{URI: c.uri("spec/service/services.hcl")},
+		"position":     Position{Line: 17, Character: 4},
+	}, nil)
+	if err == nil || err.Code != jsonrpc2.CodeMethodNotFound {
+		t.Fatalf("completion: got %v, want method not found", err)
+	}
+}
+
+func TestOutlineKeepsSameNamedBlocks(t *testing.T) {
+	c := newTestClient(t, testProject())
+
+	dup := strings.Replace(testModels, "models {\n", "models {\n  model \"User\" {\n    field \"id\" {\n      type = \"string\"\n    }\n  }\n", 1)
+	c.open("spec/schema/models.hcl", dup)
+	var symbols []DocumentSymbol
+	if err := c.request("textDocument/documentSymbol", DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{URI: c.uri("spec/schema/models.hcl")},
+	}, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 1 || len(symbols[0].Children) != 2 {
+		t.Fatalf("want both User models in the outline, got %+v", symbols)
+	}
+
+	var folds []FoldingRange
+	if err := c.request("textDocument/foldingRange", FoldingRangeParams{
+		TextDocument: TextDocumentIdentifier{URI: c.uri("spec/schema/models.hcl")},
+	}, &folds); err != nil {
+		t.Fatal(err)
+	}
+	if len(folds) != 5 {
+		t.Fatalf("want 5 folding ranges, got %+v", folds)
+	}
+}
@@ -0,0 +1,182 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// testClient drives a Handler in-process over jsonrpc2, like an editor would.
+type testClient struct {
+	t    *testing.T
+	root string
+	conn *jsonrpc2.Conn
+
+	mu      sync.Mutex
+	diags   map[string][]Diagnostic // path -> last published diagnostics
+	counts  map[string]int          // path -> number of publishes
+	updated chan struct{}           // closed and replaced on every publish
+
+	version int // of the last document opened or changed
+}
+
+// newTestClient writes files (relative path -> content) into a temp project,
+// starts a server on one end of a pipe and initializes it with the project root.
+func newTestClient(t *testing.T, files map[string]string) *testClient {
+	t.Helper()
+	root := writeProject(t, files)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serverSide, clientSide := net.Pipe()
+	handler := NewHandler()
+	server := Serve(ctx, serverSide, handler)
+	handler.exit = func() { _ = server.Close() }
+	t.Cleanup(func() {
+		_ = server.Close()
+		cancel()
+	})
+	return connectTestClient(t, root, clientSide)
+}
+
+// writeProject writes files (relative path -> content) into a temp directory
+// and returns it.
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// connectTestClient speaks to a server over nc and initializes it with root.
+func connectTestClient(t *testing.T, root string, nc net.Conn) *testClient {
+	t.Helper()
+	c := &testClient{t: t, root: root, diags: map[string][]Diagnostic{}, counts: map[string]int{}, updated: make(chan struct{})}
+	stream := jsonrpc2.NewBufferedStream(nc, jsonrpc2.VSCodeObjectCodec{})
+	c.conn = jsonrpc2.NewConn(context.Background(), stream, jsonrpc2.HandlerWithError(c.handle))
+	t.Cleanup(func() { _ = c.conn.Close() })
+
+	c.request("initialize", InitializeParams{RootURI: PathToUri(root)}, nil)
+	c.notify("initialized", struct{}{})
+	return c
+}
+
+// handle records the notifications the server sends to the client.
+func (c *testClient) handle(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+	if req.Method != "textDocument/publishDiagnostics" || req.Params == nil {
+		return nil, nil
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+	path, err := UriToPath(params.URI)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.diags[path] = params.Diagnostics
+	c.counts[path]++
+	close(c.updated)
+	c.updated = make(chan struct{})
+	c.mu.Unlock()
+	return nil, nil
+}
+
+func (c *testClient) path(name string) string {
+	return filepath.Join(c.root, filepath.FromSlash(name))
+}
+
+func (c *testClient) uri(name string) string {
+	return PathToUri(c.path(name))
+}
+
+func (c *testClient) request(method string, params, result interface{}) *jsonrpc2.Error {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := c.conn.Call(ctx, method, params, result)
+	if err == nil {
+		return nil
+	}
+	if rpcErr, ok := err.(*jsonrpc2.Error); ok {
+		return rpcErr
+	}
+	c.t.Fatalf("%s: %v", method, err)
+	return nil
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	if err := c.conn.Notify(context.Background(), method, params); err != nil {
+		c.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+func (c *testClient) open(name, text string) {
+	c.version++
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: c.uri(name), LanguageID: "hcl", Version: c.version, Text: text},
+	})
+}
+
+func (c *testClient) change(name, text string) {
+	c.version++
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: c.uri(name), Version: c.version},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+	})
+}
+
+// waitDiagnostics waits until the diagnostics last published for name
+// satisfy ok and returns them.
+func (c *testClient) waitDiagnostics(name string, ok func([]Diagnostic) bool) []Diagnostic {
+	c.t.Helper()
+	path := c.path(name)
+	timeout := time.After(10 * time.Second)
+	for {
+		c.mu.Lock()
+		diags, published := c.diags[path]
+		updated := c.updated
+		c.mu.Unlock()
+		if published && ok(diags) {
+			return diags
+		}
+		select {
+		case <-updated:
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for diagnostics on %s, last: %+v", name, diags)
+		}
+	}
+}
+
+// publishCount returns how often diagnostics were published for name.
+func (c *testClient) publishCount(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[c.path(name)]
+}
+
+// hasCode reports whether diags contain a diagnostic with code.
+func hasCode(diags []Diagnostic, code string) bool {
+	for _, d := range diags {
+		if d.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+package lsp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kwizyHQ/irex/internal/core/ast"
+	"github.com/kwizyHQ/irex/internal/core/normalize"
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/symbols"
+	"github.com/kwizyHQ/irex/internal/engines"
+	"github.com/kwizyHQ/irex/internal/ir"
+	"github.com/kwizyHQ/irex/internal/plan"
+	"github.com/kwizyHQ/irex/internal/tempdir"
+)
+
+// lensCache holds the code lenses of a document at one version of it and of
+// the workspace.
+type lensCache struct {
+	version int
+	edits   uint64
+	lenses  []CodeLens
+}
+
+// outputsCache holds the schema output paths of every model, relative to
+// the config directory, for the workspace at edits.
+type outputsCache struct {
+	edits   uint64
+	outputs map[string][]string
+}
+
+// documentVersion returns the editor's version of uri and the number of
+// edits made to the workspace so far.
+func (h *Handler) documentVersion(uri string) (int, uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.versions[uri], h.edits
+}
+
+// editCount returns the number of edits made to the workspace so far.
+func (h *Handler) editCount() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.edits
+}
+
+// codeLenses shows, above each service block, the routes it generates and,
+// above each model block, the files generated for it. Both get a lens that
+// runs the irex.previewGenerated command. Routes come from building the
+// project; the files of a model are only worked out when its lens is
+// resolved. Lenses are cached until the document or the workspace changes.
+func (h *Handler) codeLenses(uri string) []CodeLens {
+	lenses := make([]CodeLens, 0)
+	text, ok := h.documentText(uri)
+	if !ok {
+		return lenses
+	}
+	filename, _ := UriToPath(uri)
+	fileType := pipeline.GetFileType(filename)
+	if fileType != "service" && fileType != "schema" {
+		return lenses
+	}
+	version, edits := h.documentVersion(uri)
+	h.lensMu.Lock()
+	cached, ok := h.lenses[uri]
+	h.lensMu.Unlock()
+	if ok && cached.version == version && cached.edits == edits {
+		return cached.lenses
+	}
+
+	configPath := h.currentConfig()
+	if configPath == "" {
+		return lenses
+	}
+	bundle, _ := pipeline.Build(pipeline.BuildOptions{ConfigPath: configPath, FS: h.fs})
+	if bundle == nil {
+		return lenses
+	}
+	table, _ := pipeline.WalkHCLSource(filename, []byte(text))
+
+	add := func(b *pipeline.BlockSource, title string) {
+		lenses = append(lenses, CodeLens{
+			Range:   hclRangeToLSP(b.NameRange),
+			Command: &Command{Title: title},
+		})
+	}
+	preview := func(b *pipeline.BlockSource, kind, name string) {
+		lenses = append(lenses, CodeLens{
+			Range: hclRangeToLSP(b.NameRange),
+			Command: &Command{
+				Title:     "Preview generated code",
+				Command:   commandPreviewGenerated,
+				Arguments: []interface{}{PreviewArgs{Kind: kind, Name: name}},
+			},
+		})
+	}
+	for _, b := range sortedBlocks(table) {
+		if len(b.Labels) == 0 {
+			continue
+		}
+		name := b.Labels[len(b.Labels)-1]
+		switch {
+		case fileType == "service" && b.Type == "service":
+			preview(b, "service", name)
+			for _, r := range serviceRoutes(bundle, name) {
+				add(b, fmt.Sprintf("%s %s → %s", r.Method, bundle.Http.BasePath+r.Path, r.Operation))
+			}
+		case fileType == "schema" && b.Type == "model":
+			preview(b, "model", name)
+			if _, ok := bundle.Models[name]; ok {
+				lenses = append(lenses, CodeLens{
+					Range: hclRangeToLSP(b.NameRange),
+					Data:  &CodeLensData{Model: name},
+				})
+			}
+		}
+	}
+
+	h.lensMu.Lock()
+	h.lenses[uri] = lensCache{version: version, edits: edits, lenses: lenses}
+	h.lensMu.Unlock()
+	return lenses
+}
+
+// resolveCodeLens fills in the files generated for the model of lens. The
+// output paths are shared by every lens resolved before the workspace changes.
+func (h *Handler) resolveCodeLens(lens CodeLens) CodeLens {
+	if lens.Command != nil || lens.Data == nil {
+		return lens
+	}
+	edits := h.editCount()
+	h.lensMu.Lock()
+	if h.outputs == nil || h.outputs.edits != edits {
+		h.outputs = &outputsCache{edits: edits, outputs: h.modelOutputs()}
+	}
+	outputs := h.outputs.outputs[lens.Data.Model]
+	h.lensMu.Unlock()
+
+	title := "→ no generated files"
+	if len(outputs) > 0 {
+		title = "→ " + strings.Join(outputs, ", ")
+	}
+	lens.Command = &Command{Title: title}
+	return lens
+}
+
+// serviceRoutes returns the routes of service sorted by path, then method.
+func serviceRoutes(bundle *ir.IRBundle, service string) []ir.IRRoute {
+	var routes []ir.IRRoute
+	for _, r := range bundle.Routes {
+		if r.Service == service {
+			routes = append(routes, r)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// modelOutputs evaluates the output patterns of the schema templates for
+// every model, without rendering them, and returns the sorted paths of each
+// model relative to the config directory. It returns nil when the project
+// does not build.
+func (h *Handler) modelOutputs() map[string][]string {
+	configPath := h.currentConfig()
+	if configPath == "" {
+		return nil
+	}
+	bundle, _ := pipeline.Build(pipeline.BuildOptions{ConfigPath: configPath, FS: h.fs})
+	if bundle == nil {
+		return nil
+	}
+	if _, ok := engines.RenderPlans[bundle.Config.Runtime.Name]; !ok {
+		return nil
+	}
+	ctx := &plan.PlanContext{
+		TargetDir:         bundle.Config.Paths.Root,
+		IR:                bundle,
+		TmpDir:            tempdir.Get(),
+		CompiledTemplates: make(plan.CompiledTemplates),
+	}
+	paths, err := engines.OutputPaths(ctx, plan.TemplateTypeSchema)
+	if err != nil {
+		return nil
+	}
+	base := filepath.Dir(configPath)
+	out := make(map[string][]string, len(paths))
+	for model, outputs := range paths {
+		for _, p := range outputs {
+			path := filepath.Join(bundle.Config.Paths.Output, p)
+			if rel, err := filepath.Rel(base, path); err == nil {
+				path = rel
+			}
+			out[model] = append(out[model], filepath.ToSlash(path))
+		}
+		sort.Strings(out[model])
+	}
+	return out
+}
+
+// inlayHints shows the settings a service inherits from enclosing defaults
+// blocks at the end of its header line.
+func (h *Handler) inlayHints(uri string, rng Range) []InlayHint {
+	hints := make([]InlayHint, 0)
+	text, ok := h.documentText(uri)
+	if !ok {
+		return hints
+	}
+	filename, _ := UriToPath(uri)
+	if pipeline.GetFileType(filename) != "service" {
+		return hints
+	}
+	var def symbols.ServiceDefinition
+	if diags := ast.ParseFromHCLContentWith(filename, text, &def, pipeline.ProjectDecodeOptions(h.currentConfig(), h.fs)); len(diags) > 0 {
+		return hints
+	}
+	inherited := normalize.InheritedDefaults(&def)
+	table, _ := pipeline.WalkHCLSource(filename, []byte(text))
+	lines := strings.Split(text, "\n")
+
+	for _, b := range sortedBlocks(table) {
+		values, ok := inherited[b.Path]
+		if !ok {
+			continue
+		}
+		line := b.DefRange.Start.Line - 1
+		if line < rng.Start.Line || line > rng.End.Line || line >= len(lines) {
+			continue
+		}
+		pos := offsetToPosition(lines[line], len(strings.TrimRight(lines[line], " \t\r")))
+		pos.Line = line
+		for _, v := range values {
+			hints = append(hints, InlayHint{
+				Position:    pos,
+				Label:       v.Name + " = " + hclValueString(v.Value),
+				Tooltip:     "Inherited from defaults",
+				PaddingLeft: true,
+			})
+		}
+	}
+	return hints
+}
+
+// hclValueString renders a default value the way it would be written in HCL.
+func hclValueString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -1,7 +1,28 @@
 package lsp
 
+import "encoding/json"
+
 // Minimal subset of LSP protocol types used by this server.
 
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+type InitializeParams struct {
+	RootURI               string                `json:"rootUri,omitempty"`
+	RootPath              string                `json:"rootPath,omitempty"`
+	WorkspaceFolders      []WorkspaceFolder     `json:"workspaceFolders,omitempty"`
+	InitializationOptions InitializationOptions `json:"initializationOptions,omitempty"`
+}
+
+// InitializationOptions are the irex specific settings a client may send.
+type InitializationOptions struct {
+	// CanonicalFormat makes formatting also sort attributes, matching
+	// `irex format --canonical`.
+	CanonicalFormat bool `json:"canonicalFormat,omitempty"`
+}
+
 type Position struct {
 	Line      int `json:"line"`
 	Character int `json:"character"`
@@ -13,11 +34,31 @@ type Range struct {
 }
 
 type Diagnostic struct {
-	Range    Range  `json:"range"`
-	Severity int    `json:"severity,omitempty"`
-	Code     any    `json:"code,omitempty"`
-	Source   string `json:"source,omitempty"`
-	Message  string `json:"message"`
+	Range    Range `json:"range"`
+	Severity int   `json:"severity,omitempty"`
+	Code     any   `json:"code,omitempty"`
+	// CodeDescription links the code to its entry in the diagnostic catalog.
+	CodeDescription *CodeDescription `json:"codeDescription,omitempty"`
+	Source          string           `json:"source,omitempty"`
+	Message         string           `json:"message"`
+	Data            *DiagnosticData  `json:"data,omitempty"`
+	// RelatedInformation points at other definitions involved, e.g. the
+	// first definition of a duplicate.
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+type CodeDescription struct {
+	Href string `json:"href"`
+}
+
+// DiagnosticData is round-tripped by the client into code action requests.
+type DiagnosticData struct {
+	HclPath string `json:"hclPath,omitempty"`
 }
 
 type PublishDiagnosticsParams struct {
@@ -44,7 +85,169 @@ type TextDocumentContentChangeEvent struct {
 	Text string `json:"text"`
 }
 
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
 type DidChangeTextDocumentParams struct {
-	TextDocument   TextDocumentIdentifier           `json:"textDocument"`
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
 	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
 }
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+}
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type SymbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type FoldingRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type FoldingRange struct {
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	Kind      string `json:"kind,omitempty"`
+}
+
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+type Command struct {
+	Title     string        `json:"title"`
+	Command   string        `json:"command"`
+	Arguments []interface{} `json:"arguments,omitempty"`
+}
+
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLens struct {
+	Range   Range         `json:"range"`
+	Command *Command      `json:"command,omitempty"`
+	Data    *CodeLensData `json:"data,omitempty"`
+}
+
+// CodeLensData marks a lens whose command codeLens/resolve fills in.
+type CodeLensData struct {
+	Model string `json:"model"` // the model whose generated files the lens lists
+}
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type InlayHint struct {
+	Position    Position `json:"position"`
+	Label       string   `json:"label"`
+	Tooltip     string   `json:"tooltip,omitempty"`
+	PaddingLeft bool     `json:"paddingLeft,omitempty"`
+}
+
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+// PreviewArgs is the argument of the irex.previewGenerated command.
+type PreviewArgs struct {
+	Kind string `json:"kind"` // "model" or "service"
+	Name string `json:"name"`
+}
+
+// PreviewFile is one rendered file, addressable as a virtual document.
+type PreviewFile struct {
+	URI      string `json:"uri"`
+	Path     string `json:"path"`
+	Template string `json:"template"`
+	Content  string `json:"content"`
+}
+
+type PreviewResult struct {
+	Kind  string        `json:"kind"`
+	Name  string        `json:"name"`
+	Files []PreviewFile `json:"files"`
+}
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokensRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type SemanticTokens struct {
+	Data []int `json:"data"`
+}
@@ -0,0 +1,278 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/functions"
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Semantic token legend. The index of each entry is what is sent on the wire.
+var (
+	semanticTokenTypes = []string{
+		"class",     // models
+		"namespace", // services
+		"interface", // policies
+		"event",     // rate limits
+		"type",      // field types
+		"macro",     // policy rule expressions
+		"property",  // ctx.* paths in count_key
+		"function",  // env(), only(), ...
+	}
+	semanticTokenModifiers = []string{
+		"declaration",
+		"defaultLibrary",
+		"unresolved", // references to names that are not defined
+	}
+)
+
+const (
+	tokenModel = iota
+	tokenService
+	tokenPolicy
+	tokenRateLimit
+	tokenFieldType
+	tokenRule
+	tokenContextPath
+	tokenFunction
+)
+
+const (
+	modDeclaration = 1 << iota
+	modDefaultLibrary
+	modUnresolved
+)
+
+type semanticToken struct {
+	start, end int // byte offsets, on one line
+	tokenType  int
+	modifiers  int
+}
+
+// definedNames holds the names references may resolve to.
+type definedNames struct {
+	models, policies, rateLimits map[string]struct{}
+}
+
+// semanticTokens classifies the irex specific tokens of a document and
+// returns them in the relative encoding of the protocol. Only tokens on
+// lines within rng are returned when rng is non-nil.
+func (h *Handler) semanticTokens(uri string, rng *Range) SemanticTokens {
+	text, ok := h.documentText(uri)
+	if !ok {
+		return SemanticTokens{Data: []int{}}
+	}
+	body := parseSyntax(text, uri)
+	if body == nil {
+		return SemanticTokens{Data: []int{}}
+	}
+	names := h.definedNames(uri, body)
+	var tokens []semanticToken
+	collectTokens(body, "", "", []byte(text), names, &tokens)
+	return encodeTokens(text, tokens, rng)
+}
+
+// definedNames gathers models from the whole project and policies and rate
+// limits from the service files, with the document's own definitions on top.
+func (h *Handler) definedNames(uri string, doc *hclsyntax.Body) definedNames {
+	names := definedNames{
+		models:     map[string]struct{}{},
+		policies:   map[string]struct{}{},
+		rateLimits: map[string]struct{}{},
+	}
+	bodies := []*hclsyntax.Body{doc}
+	if cfg := h.currentConfig(); cfg != "" {
+		for _, m := range pipeline.LoadModels(cfg, h.fs) {
+			names.models[m.Name] = struct{}{}
+		}
+		_, serviceFiles := pipeline.SpecFiles(cfg, h.fs)
+		for _, path := range serviceFiles {
+			if src, err := h.fs.ReadFile(path); err == nil {
+				if b := parseSyntax(string(src), PathToUri(path)); b != nil {
+					bodies = append(bodies, b)
+				}
+			}
+		}
+	}
+	for _, body := range bodies {
+		for _, b := range body.Blocks {
+			for _, inner := range b.Body.Blocks {
+				if len(inner.Labels) == 0 {
+					continue
+				}
+				switch {
+				case b.Type == "models" && inner.Type == "model":
+					names.models[inner.Labels[0]] = struct{}{}
+				case b.Type == "policies" && (inner.Type == "policy" || inner.Type == "custom" || inner.Type == "group"):
+					names.policies[inner.Labels[0]] = struct{}{}
+				case b.Type == "rate_limits" && (inner.Type == "preset" || inner.Type == "custom"):
+					names.rateLimits[inner.Labels[0]] = struct{}{}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// collectTokens walks body, whose enclosing block has type blockType and
+// whose parent block has type parentType.
+func collectTokens(body *hclsyntax.Body, blockType, parentType string, src []byte, names definedNames, out *[]semanticToken) {
+	add := func(r hcl.Range, tokenType, modifiers int) {
+		if r.Start.Line != r.End.Line || r.End.Byte <= r.Start.Byte {
+			return
+		}
+		*out = append(*out, semanticToken{start: r.Start.Byte, end: r.End.Byte, tokenType: tokenType, modifiers: modifiers})
+	}
+	reference := func(r hcl.Range, tokenType int, defined map[string]struct{}, name string) {
+		mods := 0
+		if _, ok := defined[name]; !ok {
+			mods = modUnresolved
+		}
+		add(r, tokenType, mods)
+	}
+
+	for name, attr := range body.Attributes {
+		hclsyntax.VisitAll(attr.Expr, func(n hclsyntax.Node) hcl.Diagnostics {
+			if call, ok := n.(*hclsyntax.FunctionCallExpr); ok {
+				mods := modDefaultLibrary
+				if _, known := functions.ASTFunctions[call.Name]; !known {
+					mods = modUnresolved
+				}
+				add(call.NameRange, tokenFunction, mods)
+			}
+			return nil
+		})
+
+		switch {
+		case name == "model" && blockType == "service",
+			name == "ref" && (blockType == "manyToMany" || blockType == "hasMany" || blockType == "belongsTo"):
+			for _, s := range stringLiterals(attr.Expr) {
+				reference(s.rng, tokenModel, names.models, s.value)
+			}
+		case name == "type" && blockType == "field":
+			for _, s := range stringLiterals(attr.Expr) {
+				add(s.rng, tokenFieldType, 0)
+			}
+		case name == "rule" && parentType == "policies":
+			for _, s := range stringLiterals(attr.Expr) {
+				add(s.rng, tokenRule, 0)
+			}
+		case name == "count_key":
+			for _, s := range stringLiterals(attr.Expr) {
+				if strings.HasPrefix(s.value, "ctx.") {
+					add(s.rng, tokenContextPath, 0)
+				}
+			}
+		case name == "policies" && blockType == "service":
+			for _, s := range stringLiterals(attr.Expr) {
+				reference(s.rng, tokenPolicy, names.policies, s.value)
+			}
+		case name == "rate_limits" && blockType == "apply":
+			for _, s := range stringLiterals(attr.Expr) {
+				reference(s.rng, tokenRateLimit, names.rateLimits, s.value)
+			}
+		}
+	}
+
+	for _, b := range body.Blocks {
+		if len(b.Labels) > 0 {
+			first := labelRange(src, b.LabelRanges[0])
+			switch {
+			case b.Type == "model" && blockType == "models":
+				add(first, tokenModel, modDeclaration)
+			case b.Type == "service":
+				add(first, tokenService, modDeclaration)
+			case blockType == "policies" && (b.Type == "policy" || b.Type == "custom" || b.Type == "group"):
+				add(first, tokenPolicy, modDeclaration)
+			case blockType == "rate_limits" && (b.Type == "preset" || b.Type == "custom"):
+				add(first, tokenRateLimit, modDeclaration)
+			case b.Type == "apply" && len(b.Labels) > 1:
+				target := labelRange(src, b.LabelRanges[1])
+				switch b.Labels[0] {
+				case "policy":
+					reference(target, tokenPolicy, names.policies, b.Labels[1])
+				case "rate_limit":
+					reference(target, tokenRateLimit, names.rateLimits, b.Labels[1])
+				}
+			}
+		}
+		collectTokens(b.Body, b.Type, blockType, src, names, out)
+	}
+}
+
+type stringLiteral struct {
+	value string
+	rng   hcl.Range
+}
+
+// stringLiterals returns the plain string literals of expr, itself or the
+// elements of a tuple, with ranges that exclude the quotes.
+func stringLiterals(expr hclsyntax.Expression) []stringLiteral {
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateExpr:
+		if len(e.Parts) != 1 {
+			return nil
+		}
+		lit, ok := e.Parts[0].(*hclsyntax.LiteralValueExpr)
+		if !ok || !lit.Val.IsKnown() || lit.Val.IsNull() || lit.Val.Type() != cty.String {
+			return nil
+		}
+		return []stringLiteral{{value: lit.Val.AsString(), rng: lit.SrcRange}}
+	case *hclsyntax.TupleConsExpr:
+		var out []stringLiteral
+		for _, el := range e.Exprs {
+			out = append(out, stringLiterals(el)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// labelRange drops the quotes around a quoted block label.
+func labelRange(src []byte, r hcl.Range) hcl.Range {
+	if r.End.Byte-r.Start.Byte >= 2 && r.Start.Byte < len(src) && src[r.Start.Byte] == '"' {
+		r.Start.Byte++
+		r.Start.Column++
+		r.End.Byte--
+		r.End.Column--
+	}
+	return r
+}
+
+// encodeTokens sorts tokens and encodes them as line/character deltas with
+// lengths in UTF-16 code units.
+func encodeTokens(text string, tokens []semanticToken, rng *Range) SemanticTokens {
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].start < tokens[j].start })
+	data := make([]int, 0, len(tokens)*5)
+	prevLine, prevChar := 0, 0
+	line, lineStart, scanned := 0, 0, 0
+	for _, t := range tokens {
+		if t.end > len(text) {
+			continue
+		}
+		// tokens are sorted, so lines only need scanning once
+		for ; scanned < t.start; scanned++ {
+			if text[scanned] == '\n' {
+				line++
+				lineStart = scanned + 1
+			}
+		}
+		pos := Position{Line: line, Character: len(utf16.Encode([]rune(text[lineStart:t.start])))}
+		if rng != nil && (pos.Line < rng.Start.Line || pos.Line > rng.End.Line) {
+			continue
+		}
+		length := len(utf16.Encode([]rune(text[t.start:t.end])))
+		deltaChar := pos.Character
+		if pos.Line == prevLine {
+			deltaChar -= prevChar
+		}
+		data = append(data, pos.Line-prevLine, deltaChar, length, t.tokenType, t.modifiers)
+		prevLine, prevChar = pos.Line, pos.Character
+	}
+	return SemanticTokens{Data: data}
+}
@@ -2,6 +2,9 @@ package lsp
 
 import (
 	"context"
+	"io"
+	"log/slog"
+	"net"
 	"os"
 
 	"github.com/sourcegraph/jsonrpc2"
@@ -18,10 +21,11 @@ func (s stdioReadWriteCloser) Close() error                { return nil }
 
 // RunServer starts an LSP-like JSON-RPC2 server over stdio.
 func RunServer(ctx context.Context) error {
+	// stdout carries the protocol, so logs from the pipeline and template
+	// steps must not end up there
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: enabledLevel(ctx)})))
 	// slog.Info("starting lsp server")
-	stream := jsonrpc2.NewBufferedStream(stdioReadWriteCloser{r: os.Stdin, w: os.Stdout}, jsonrpc2.VSCodeObjectCodec{})
-	handler := NewHandler()
-	conn := jsonrpc2.NewConn(ctx, stream, handler)
+	conn := Serve(ctx, stdioReadWriteCloser{r: os.Stdin, w: os.Stdout}, NewHandler())
 
 	// block until context is canceled
 	<-ctx.Done()
@@ -29,3 +33,58 @@ func RunServer(ctx context.Context) error {
 	// slog.Info("lsp server stopped")
 	return nil
 }
+
+// Serve runs handler on stream until the connection closes and returns the
+// connection. It is the common part of stdio, TCP and in-process sessions.
+func Serve(ctx context.Context, stream io.ReadWriteCloser, handler *Handler) *jsonrpc2.Conn {
+	return jsonrpc2.NewConn(ctx, jsonrpc2.NewBufferedStream(stream, jsonrpc2.VSCodeObjectCodec{}), handler)
+}
+
+// ListenAndServe accepts LSP clients on addr until ctx is canceled. Every
+// client gets its own Handler, and `exit` only ends that client's session.
+func ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	slog.Info("lsp server listening", "addr", ln.Addr().String())
+	return ServeListener(ctx, ln)
+}
+
+// ServeListener is ListenAndServe on an existing listener, which it closes
+// when ctx is canceled.
+func ServeListener(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		slog.Info("lsp client connected", "remote", nc.RemoteAddr().String())
+		handler := NewHandler()
+		// set before serving: `exit` may be the first message the client sends
+		handler.exit = func() { _ = nc.Close() }
+		conn := Serve(ctx, nc, handler)
+		go func() {
+			<-conn.DisconnectNotify()
+			slog.Info("lsp client disconnected", "remote", nc.RemoteAddr().String())
+		}()
+	}
+}
+
+// enabledLevel is the lowest level the current default logger accepts.
+func enabledLevel(ctx context.Context) slog.Level {
+	for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if slog.Default().Enabled(ctx, l) {
+			return l
+		}
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenAndServeIsolatesClients(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- ServeListener(ctx, ln) }()
+
+	root := writeProject(t, testProject())
+	dial := func() *testClient {
+		nc, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return connectTestClient(t, root, nc)
+	}
+	a, b := dial(), dial()
+
+	// a's unsaved buffer only reaches a's session
+	a.open("spec/service/services.hcl", strings.Replace(testServices, `"User"`, `"Usr"`, 1))
+	a.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return hasCode(d, "service.model.not_found")
+	})
+	b.open("spec/service/services.hcl", testServices)
+	b.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return !hasCode(d, "service.model.not_found")
+	})
+
+	// exit closes a's connection only
+	if err := a.request("shutdown", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	a.notify("exit", nil)
+	select {
+	case <-a.conn.DisconnectNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("exit did not close the client's connection")
+	}
+
+	b.change("spec/service/services.hcl", strings.Replace(testServices, `"User"`, `"Usr"`, 1))
+	b.waitDiagnostics("spec/service/services.hcl", func(d []Diagnostic) bool {
+		return hasCode(d, "service.model.not_found")
+	})
+	var symbols []DocumentSymbol
+	if err := b.request("textDocument/documentSymbol", DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{URI: b.uri("spec/service/services.hcl")},
+	}, &symbols); err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 3 {
+		t.Fatalf("want 3 top-level symbols, got %+v", symbols)
+	}
+
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("ServeListener: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("ServeListener did not return after cancel")
+	}
+}
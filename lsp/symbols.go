@@ -0,0 +1,191 @@
+package lsp
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+)
+
+// LSP SymbolKind values used by the outline.
+const (
+	symbolKindModule    = 2
+	symbolKindNamespace = 3
+	symbolKindClass     = 5
+	symbolKindMethod    = 6
+	symbolKindField     = 8
+	symbolKindInterface = 11
+	symbolKindConstant  = 14
+	symbolKindObject    = 19
+	symbolKindEvent     = 24
+)
+
+// blockSymbolKind maps an irex block type to the symbol kind shown in the outline.
+func blockSymbolKind(blockType string) int {
+	switch blockType {
+	case "project", "models", "services", "policies", "rate_limits":
+		return symbolKindNamespace
+	case "model":
+		return symbolKindClass
+	case "field":
+		return symbolKindField
+	case "service":
+		return symbolKindModule
+	case "operation":
+		return symbolKindMethod
+	case "policy", "custom", "group":
+		return symbolKindInterface
+	case "preset":
+		return symbolKindConstant
+	case "apply":
+		return symbolKindEvent
+	default:
+		return symbolKindObject
+	}
+}
+
+// hclRangeToLSP converts a 1-based HCL range to a 0-based LSP range.
+func hclRangeToLSP(r hcl.Range) Range {
+	return Range{
+		Start: Position{Line: max(r.Start.Line-1, 0), Character: max(r.Start.Column-1, 0)},
+		End:   Position{Line: max(r.End.Line-1, 0), Character: max(r.End.Column-1, 0)},
+	}
+}
+
+// blockName is the label(s) of a block, or its type when it has none.
+func blockName(b *pipeline.BlockSource) string {
+	if len(b.Labels) == 0 {
+		return b.Type
+	}
+	return strings.Join(b.Labels, " ")
+}
+
+// sortedBlocks returns the blocks of table in document order.
+func sortedBlocks(table pipeline.SymbolTable) []*pipeline.BlockSource {
+	blocks := make([]*pipeline.BlockSource, 0, len(table.Blocks))
+	for _, b := range table.Blocks {
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].DefRange.Start.Byte < blocks[j].DefRange.Start.Byte
+	})
+	return blocks
+}
+
+// syntaxBlockName is the label(s) of a block, or its type when it has none.
+func syntaxBlockName(b *hclsyntax.Block) string {
+	if len(b.Labels) == 0 {
+		return b.Type
+	}
+	return strings.Join(b.Labels, " ")
+}
+
+// documentSymbols builds the outline tree of a document from its HCL body,
+// one symbol per block, so same-named siblings each keep their own entry.
+func documentSymbols(uri, text string) []DocumentSymbol {
+	body := parseSyntax(text, uri)
+	if body == nil {
+		return []DocumentSymbol{}
+	}
+
+	var build func(blocks hclsyntax.Blocks) []DocumentSymbol
+	build = func(blocks hclsyntax.Blocks) []DocumentSymbol {
+		out := make([]DocumentSymbol, 0, len(blocks))
+		for _, b := range blocks {
+			detail := b.Type
+			if b.Type == "apply" && len(b.Labels) > 0 {
+				detail = "apply " + b.Labels[0]
+			}
+			selection := b.TypeRange
+			if n := len(b.LabelRanges); n > 0 {
+				selection = b.LabelRanges[n-1]
+			}
+			out = append(out, DocumentSymbol{
+				Name:           syntaxBlockName(b),
+				Detail:         detail,
+				Kind:           blockSymbolKind(b.Type),
+				Range:          hclRangeToLSP(b.Range()),
+				SelectionRange: hclRangeToLSP(selection),
+				Children:       build(b.Body.Blocks),
+			})
+		}
+		return out
+	}
+	return build(body.Blocks)
+}
+
+// foldingRanges returns one region per multi-line block, keeping the closing
+// brace visible.
+func foldingRanges(uri, text string) []FoldingRange {
+	out := make([]FoldingRange, 0)
+	body := parseSyntax(text, uri)
+	if body == nil {
+		return out
+	}
+
+	var walk func(blocks hclsyntax.Blocks)
+	walk = func(blocks hclsyntax.Blocks) {
+		for _, b := range blocks {
+			r := b.Range()
+			start := r.Start.Line - 1
+			end := r.End.Line - 2
+			if end > start {
+				out = append(out, FoldingRange{StartLine: start, EndLine: end, Kind: "region"})
+			}
+			walk(b.Body.Blocks)
+		}
+	}
+	walk(body.Blocks)
+	return out
+}
+
+// workspaceSymbols searches the named blocks of every spec file in the
+// project (and any open irex document) for query, case-insensitively.
+func (h *Handler) workspaceSymbols(query string) []SymbolInformation {
+	files := map[string]struct{}{}
+	if cfg := h.currentConfig(); cfg != "" {
+		files[cfg] = struct{}{}
+		schemaFiles, serviceFiles := pipeline.SpecFiles(cfg, h.fs)
+		for _, fn := range append(schemaFiles, serviceFiles...) {
+			files[fn] = struct{}{}
+		}
+	}
+	for _, fn := range h.fs.Paths() {
+		files[fn] = struct{}{}
+	}
+
+	q := strings.ToLower(query)
+	out := make([]SymbolInformation, 0)
+	for fn := range files {
+		if filepath.Ext(fn) != ".hcl" {
+			continue
+		}
+		table, _ := pipeline.WalkHCLSymbolsFS(h.fs, fn)
+		for _, b := range sortedBlocks(table) {
+			if len(b.Labels) == 0 {
+				continue
+			}
+			name := blockName(b)
+			if q != "" && !strings.Contains(strings.ToLower(name), q) {
+				continue
+			}
+			container := strings.TrimSuffix(b.Path, "."+b.Type+"."+strings.Join(b.Labels, "."))
+			out = append(out, SymbolInformation{
+				Name:          name,
+				Kind:          blockSymbolKind(b.Type),
+				Location:      Location{URI: PathToUri(fn), Range: hclRangeToLSP(b.NameRange)},
+				ContainerName: container,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Location.URI < out[j].Location.URI
+	})
+	return out
+}
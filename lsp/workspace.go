@@ -0,0 +1,175 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// initWorkspace records the client's root folder and locates the project's irex.hcl.
+func (h *Handler) initWorkspace(params InitializeParams) {
+	root := params.RootPath
+	if params.RootURI != "" {
+		if p, err := UriToPath(params.RootURI); err == nil {
+			root = p
+		}
+	}
+	if root == "" && len(params.WorkspaceFolders) > 0 {
+		if p, err := UriToPath(params.WorkspaceFolders[0].URI); err == nil {
+			root = p
+		}
+	}
+	if root == "" {
+		return
+	}
+	h.mu.Lock()
+	h.root = root
+	h.configPath = pipeline.FindConfig(h.fs, root)
+	h.mu.Unlock()
+}
+
+// discoverConfig falls back to searching from an opened document when the
+// workspace root did not lead to an irex.hcl (e.g. single-file mode).
+func (h *Handler) discoverConfig(uri string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.configPath != "" {
+		return
+	}
+	if path, err := UriToPath(uri); err == nil {
+		h.configPath = pipeline.FindConfig(h.fs, path)
+	}
+}
+
+// publishDelay is how long edits must pause before the workspace is rebuilt.
+const publishDelay = 150 * time.Millisecond
+
+// schedulePublish publishes the workspace diagnostics once edits have paused
+// for publishDelay. Runs requested while one is in progress mark the
+// workspace dirty and collapse into a single further run, so typing never
+// queues one full build per keystroke.
+func (h *Handler) schedulePublish(ctx context.Context, conn *jsonrpc2.Conn) {
+	h.scheduleMu.Lock()
+	defer h.scheduleMu.Unlock()
+	if h.publishTimer != nil {
+		h.publishTimer.Stop()
+	}
+	h.publishTimer = time.AfterFunc(publishDelay, func() { h.runPublish(ctx, conn) })
+}
+
+// runPublish runs publishWorkspace until no run has been requested since the
+// last one started.
+func (h *Handler) runPublish(ctx context.Context, conn *jsonrpc2.Conn) {
+	h.scheduleMu.Lock()
+	if h.publishing {
+		h.publishDirty = true
+		h.scheduleMu.Unlock()
+		return
+	}
+	h.publishing = true
+	h.scheduleMu.Unlock()
+	for {
+		if ctx.Err() == nil {
+			h.publishWorkspace(ctx, conn)
+		}
+		h.scheduleMu.Lock()
+		if !h.publishDirty {
+			h.publishing = false
+			h.scheduleMu.Unlock()
+			return
+		}
+		h.publishDirty = false
+		h.scheduleMu.Unlock()
+	}
+}
+
+// publishWorkspace runs the full pipeline over the project, merges in the
+// per-document diagnostics of every open buffer, and publishes the result for
+// each affected file. Files that had diagnostics last time but none now are
+// cleared, so fixing a schema clears errors in the services that use it.
+func (h *Handler) publishWorkspace(ctx context.Context, conn *jsonrpc2.Conn) {
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+
+	h.mu.Lock()
+	configPath := h.configPath
+	docs := make(map[string]string, len(h.docs))
+	for uri, text := range h.docs {
+		docs[uri] = text
+	}
+	h.mu.Unlock()
+
+	byPath := make(map[string][]Diagnostic)
+	uris := make(map[string]string)
+
+	// files outside a project still see the other open buffers
+	fc := pipeline.FileContext{FS: h.fs}
+	if configPath != "" {
+		var workspace map[string]diagnostics.Diagnostics
+		workspace, fc = pipeline.GetWorkspaceDiagnostics(configPath, h.fs)
+		for fn, diags := range workspace {
+			key := pathKey(fn)
+			byPath[key] = append(byPath[key], toLSPDiagnostics(diags)...)
+			uris[key] = PathToUri(fn)
+		}
+	}
+
+	for uri, text := range docs {
+		path, err := UriToPath(uri)
+		if err != nil {
+			continue
+		}
+		key := pathKey(path)
+		// prefer the client's spelling of the URI for open documents
+		uris[key] = uri
+		byPath[key] = mergeDiagnostics(byPath[key], computeDiagnostics(text, uri, fc))
+	}
+
+	for key, uri := range h.published {
+		if _, ok := byPath[key]; !ok {
+			_ = publishDiagnostics(ctx, conn, uri, []Diagnostic{})
+		}
+	}
+
+	h.published = make(map[string]string, len(byPath))
+	for key, diags := range byPath {
+		if diags == nil {
+			diags = []Diagnostic{}
+		}
+		_ = publishDiagnostics(ctx, conn, uris[key], diags)
+		h.published[key] = uris[key]
+	}
+}
+
+// mergeDiagnostics appends extra to base, skipping entries already present.
+func mergeDiagnostics(base, extra []Diagnostic) []Diagnostic {
+	seen := make(map[string]struct{}, len(base))
+	for _, d := range base {
+		seen[diagnosticKey(d)] = struct{}{}
+	}
+	for _, d := range extra {
+		k := diagnosticKey(d)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		base = append(base, d)
+	}
+	return base
+}
+
+func diagnosticKey(d Diagnostic) string {
+	return fmt.Sprintf("%d:%d-%d:%d|%v|%s", d.Range.Start.Line, d.Range.Start.Character, d.Range.End.Line, d.Range.End.Character, d.Code, d.Message)
+}
+
+func pathKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
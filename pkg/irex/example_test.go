@@ -0,0 +1,93 @@
+package irex_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kwizyHQ/irex/pkg/irex"
+)
+
+func ExampleBuild() {
+	root, err := os.MkdirTemp("", "irex-example-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(filepath.Join(root, "spec", "schema"), 0755)
+	os.MkdirAll(filepath.Join(root, "spec", "service"), 0755)
+
+	// The service spec is an unsaved buffer: the overlay serves it in
+	// place of the file on disk.
+	fsys := irex.NewOverlay(nil)
+	os.WriteFile(filepath.Join(root, "irex.hcl"), []byte(`
+project {
+  name    = "shop"
+  version = "1.0.0"
+  generator {
+    schema  = true
+    service = true
+  }
+  paths {
+    specifications = "./spec"
+    output         = "./out"
+  }
+  runtime {
+    name = "node-ts"
+    options {
+      package_manager = "npm"
+    }
+    schema {
+      framework = "mongoose"
+      options {
+        uri = "mongodb://localhost:27017"
+        db  = "shop"
+      }
+    }
+    service {
+      framework = "fastify"
+      options {
+        port = 8080
+      }
+    }
+  }
+}
+`), 0644)
+	os.WriteFile(filepath.Join(root, "spec", "schema", "models.hcl"), []byte(`
+models {
+  model "Product" {
+    field "name" {
+      type     = "string"
+      required = true
+    }
+  }
+}
+`), 0644)
+	fsys.Set(filepath.Join(root, "spec", "service", "services.hcl"), []byte(`
+policies {}
+rate_limits {}
+services {
+  service "products" {
+    model           = "Product"
+    path            = "/products"
+    crud_operations = ["read", "list"]
+  }
+}
+`))
+
+	project, diags := irex.Build(irex.Options{ConfigPath: filepath.Join(root, "irex.hcl"), FS: fsys})
+	for _, d := range diags {
+		if d.Severity == irex.SeverityError {
+			fmt.Println(d.Code, d.Message)
+		}
+	}
+	if project == nil {
+		return
+	}
+	for _, r := range project.Routes {
+		fmt.Println(r.Method, r.Path)
+	}
+	// Output:
+	// GET /products
+	// GET /products/:id
+}
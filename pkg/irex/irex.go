@@ -0,0 +1,313 @@
+// Package irex embeds the irex pipeline: it builds a project, reporting its
+// models, services and routes along with its diagnostics, and renders the
+// project's templates to an in-memory file set.
+//
+// # Compatibility
+//
+// This package follows semantic versioning with the module. Within a major
+// version the identifiers it declares are not removed or changed in
+// incompatible ways. Its struct types may gain fields, so construct them
+// with field names. Diagnostic messages are for people and may change;
+// match on Diagnostic.Code, which is stable. Project is a view of the
+// representation the generators read, which is internal and changes with
+// them; the view does not.
+package irex
+
+import (
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/kwizyHQ/irex/internal/core/overlay"
+	"github.com/kwizyHQ/irex/internal/core/pipeline"
+	"github.com/kwizyHQ/irex/internal/core/resolve"
+	"github.com/kwizyHQ/irex/internal/core/shared"
+	"github.com/kwizyHQ/irex/internal/diagnostics"
+	"github.com/kwizyHQ/irex/internal/engines"
+	"github.com/kwizyHQ/irex/internal/plan"
+	"github.com/kwizyHQ/irex/internal/tempdir"
+)
+
+// Severity ranks a diagnostic, most severe first.
+type Severity int
+
+const (
+	SeverityError       Severity = 1
+	SeverityWarning     Severity = 2
+	SeverityInformation Severity = 3
+	SeverityHint        Severity = 4
+)
+
+// Position is a location in a spec file. Line and Column start at 1; Byte
+// is the offset from the start of the file.
+type Position struct {
+	Line   int
+	Column int
+	Byte   int
+}
+
+// Range spans Start to End, End excluded. It is zero when a problem has no
+// location in a file.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// Diagnostic is one problem found in a project.
+type Diagnostic struct {
+	Severity Severity
+	// Code identifies the kind of problem, such as
+	// "irex.input.duplicate"; irex explain <code> describes it.
+	Code     string
+	Message  string
+	Filename string
+	Range    Range
+	// Related points at other locations that explain the problem, such as
+	// the first definition of a duplicate.
+	Related []Related
+}
+
+// Related is a secondary location of a Diagnostic. An empty Filename means
+// the file of its diagnostic.
+type Related struct {
+	Message  string
+	Filename string
+	Range    Range
+}
+
+// Diagnostics is a list of problems.
+type Diagnostics []Diagnostic
+
+// MaxSeverity returns the most severe level in d, or 0 when d is empty.
+func (d Diagnostics) MaxSeverity() Severity {
+	var max Severity
+	for _, diag := range d {
+		if max == 0 || diag.Severity < max {
+			max = diag.Severity
+		}
+	}
+	return max
+}
+
+func fromDiagnostics(in diagnostics.Diagnostics) Diagnostics {
+	out := make(Diagnostics, 0, len(in))
+	for _, d := range in {
+		diag := Diagnostic{
+			Severity: Severity(d.Severity),
+			Code:     d.Code,
+			Message:  d.Message,
+			Filename: d.Filename,
+			Range:    fromRange(d.Range),
+		}
+		for _, r := range d.Related {
+			diag.Related = append(diag.Related, Related{Message: r.Message, Filename: r.Filename, Range: fromRange(r.Range)})
+		}
+		out = append(out, diag)
+	}
+	return out
+}
+
+func fromRange(r diagnostics.Range) Range {
+	return Range{
+		Start: Position{Line: r.Start.Line, Column: r.Start.Column, Byte: r.Start.Byte},
+		End:   Position{Line: r.End.Line, Column: r.End.Column, Byte: r.End.Byte},
+	}
+}
+
+// FS is where specs are read from. Paths are those of the operating
+// system; nil reads the disk.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (fs.FileInfo, error)
+	Glob(pattern string) ([]string, error)
+}
+
+// Overlay is an FS that serves buffered contents in place of the files
+// beneath it, e.g. unsaved editor buffers.
+type Overlay struct {
+	o *overlay.Overlay
+}
+
+// NewOverlay returns an empty Overlay on top of base (the disk when nil).
+func NewOverlay(base FS) *Overlay {
+	return &Overlay{o: overlay.New(base)}
+}
+
+// Set serves content for the file at name, which need not exist beneath.
+func (o *Overlay) Set(name string, content []byte) {
+	o.o.Set(name, content)
+}
+
+// Delete drops the content Set for name, uncovering the file beneath.
+func (o *Overlay) Delete(name string) {
+	o.o.Delete(name)
+}
+
+// ReadFile, Stat and Glob implement FS.
+func (o *Overlay) ReadFile(name string) ([]byte, error) {
+	return o.o.ReadFile(name)
+}
+
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	return o.o.Stat(name)
+}
+
+func (o *Overlay) Glob(pattern string) ([]string, error) {
+	return o.o.Glob(pattern)
+}
+
+// Options select the project to build and how.
+type Options struct {
+	// ConfigPath is the project's irex.hcl. Relative paths in it are
+	// resolved against its directory.
+	ConfigPath string
+	// FS overrides the file system specs are read from; nil reads the disk.
+	FS FS
+	// Profile selects the profile blocks merged onto the specs. Empty falls
+	// back to IREX_PROFILE.
+	Profile string
+	// Vars and VarFiles override the defaults of the variables block, as
+	// --var and --var-file do; Vars win.
+	Vars     map[string]string
+	VarFiles []string
+}
+
+func (o Options) build() (*shared.IRBundle, Diagnostics) {
+	bundle, diags := pipeline.Build(pipeline.BuildOptions{
+		ConfigPath: o.ConfigPath,
+		FS:         o.FS,
+		Vars:       resolve.VarOptions{Files: o.VarFiles, Values: o.Vars},
+		Profile:    o.Profile,
+	})
+	if diags.MaxSeverity() == diagnostics.SeverityError {
+		bundle = nil
+	}
+	return bundle, fromDiagnostics(diags)
+}
+
+// Build validates the project and returns it, or nil when it has errors.
+// Diagnostics are returned in both cases.
+func Build(opts Options) (*Project, Diagnostics) {
+	bundle, diags := opts.build()
+	if bundle == nil {
+		return nil, diags
+	}
+	return fromIR(bundle), diags
+}
+
+// Cardinality tells how a DataProvider's value is rendered.
+type Cardinality int
+
+const (
+	// Single renders each matching template once with the value.
+	Single Cardinality = iota + 1
+	// Many renders each matching template once per element of a []any.
+	Many
+)
+
+// DataProvider feeds the templates whose data attribute is DataKey. Use keys
+// of your own, such as "acme:routes", to add templates next to the engine's.
+type DataProvider interface {
+	DataKey() string
+	Resolve(project *Project) (any, Cardinality)
+}
+
+// RenderOptions extend an engine's rendering.
+type RenderOptions struct {
+	// Providers supply data for templates declared in the project's
+	// templates directory.
+	Providers []DataProvider
+	// Funcs are available to every template and replace engine functions
+	// of the same name.
+	Funcs template.FuncMap
+}
+
+// File is one rendered file.
+type File struct {
+	// Path is relative to the project's output directory, slash separated.
+	Path    string
+	Content []byte
+	// Template is the template that produced the file.
+	Template string
+	// Type is the template set, "schema" or "service".
+	Type string
+	// DataKey is the key of the provider that supplied the data.
+	DataKey string
+	// Item names the model or service a per-item template was rendered
+	// for, and is empty for single templates.
+	Item string
+}
+
+// Result is the outcome of Render.
+type Result struct {
+	// Project is nil when the project has errors, and Files is then empty.
+	Project     *Project
+	Files       []File
+	Diagnostics Diagnostics
+}
+
+// Render builds the project and renders its templates in memory; nothing
+// is written to disk. The error reports a failure of the engine, such as a
+// template that does not execute; problems with the specs are diagnostics.
+func Render(opts Options, ropts RenderOptions) (*Result, error) {
+	bundle, diags := opts.build()
+	result := &Result{Diagnostics: diags}
+	if bundle == nil {
+		return result, nil
+	}
+	result.Project = fromIR(bundle)
+
+	tmp, err := tempdir.New()
+	if err != nil {
+		return result, err
+	}
+	defer tmp.Delete()
+
+	ctx := &plan.PlanContext{
+		TargetDir:         bundle.Config.Paths.Root,
+		IR:                bundle,
+		TmpDir:            tmp,
+		CompiledTemplates: make(plan.CompiledTemplates),
+		RenderSession:     &plan.RenderSession{},
+		TemplateFuncs:     ropts.Funcs,
+	}
+	for _, p := range ropts.Providers {
+		ctx.Providers = append(ctx.Providers, providerAdapter{p: p, project: result.Project})
+	}
+	if err := engines.Render(ctx); err != nil {
+		return result, err
+	}
+
+	for _, f := range ctx.RenderSession.Files {
+		result.Files = append(result.Files, File{
+			Path:     filepath.ToSlash(filepath.Clean(f.OutputPath)),
+			Content:  f.Content,
+			Template: f.Name,
+			Type:     string(f.Type),
+			DataKey:  f.DataKey,
+			Item:     f.Item,
+		})
+	}
+	slices.SortStableFunc(result.Files, func(a, b File) int { return strings.Compare(a.Path, b.Path) })
+	return result, nil
+}
+
+// providerAdapter runs a public DataProvider as an engine one.
+type providerAdapter struct {
+	p       DataProvider
+	project *Project
+}
+
+func (a providerAdapter) DataKey() string {
+	return a.p.DataKey()
+}
+
+func (a providerAdapter) Resolve(ctx *plan.PlanContext) (any, plan.Cardinality) {
+	data, card := a.p.Resolve(a.project)
+	if card == Many {
+		return data, plan.Many
+	}
+	return data, plan.Single
+}
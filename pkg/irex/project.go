@@ -0,0 +1,108 @@
+package irex
+
+import (
+	"sort"
+
+	"github.com/kwizyHQ/irex/internal/ir"
+)
+
+// Project is what a build knows about a project: its models, services and
+// routes. It is a stable view of the representation the generators read,
+// which stays internal.
+type Project struct {
+	Name    string
+	Version string
+	// BasePath prefixes the path of every route when it is served.
+	BasePath string
+	// Models and Services are sorted by name, Routes by path and then
+	// method.
+	Models   []Model
+	Services []Service
+	Routes   []Route
+}
+
+// Model is a model of the schema specs. Models of spec modules are named
+// with the module's prefix, such as "auth.User".
+type Model struct {
+	Name   string
+	Fields []Field
+}
+
+// Field is a field of a model, or of an embedded object field.
+type Field struct {
+	Name        string
+	Type        string
+	Required    bool
+	Unique      bool
+	Description string
+	// Fields are the fields of an embedded object.
+	Fields []Field
+}
+
+// Service is a service of the service specs.
+type Service struct {
+	Name string
+	// Model is the model the service exposes, empty for custom services.
+	Model string
+	// Parent is the service this one is nested in, if any.
+	Parent string
+	// Exposed is false for services that generate no routes.
+	Exposed bool
+}
+
+// Route is an HTTP route the project serves.
+type Route struct {
+	Method string
+	// Path excludes the BasePath, with parameters written as :name.
+	Path      string
+	Service   string
+	Operation string
+}
+
+func fromIR(b *ir.IRBundle) *Project {
+	p := &Project{
+		Name:     b.Config.Project.Name,
+		Version:  b.Config.Project.Version,
+		BasePath: b.Http.BasePath,
+	}
+	for _, m := range b.Models {
+		p.Models = append(p.Models, Model{Name: m.Name, Fields: fromFields(m.Fields)})
+	}
+	sort.Slice(p.Models, func(i, j int) bool { return p.Models[i].Name < p.Models[j].Name })
+
+	for _, s := range b.Services {
+		p.Services = append(p.Services, Service{
+			Name:    s.Name,
+			Model:   s.Model,
+			Parent:  s.Parent,
+			Exposed: s.Expose == nil || *s.Expose,
+		})
+	}
+	sort.Slice(p.Services, func(i, j int) bool { return p.Services[i].Name < p.Services[j].Name })
+
+	for _, r := range b.Routes {
+		p.Routes = append(p.Routes, Route{Method: r.Method, Path: r.Path, Service: r.Service, Operation: r.Operation})
+	}
+	sort.Slice(p.Routes, func(i, j int) bool {
+		if p.Routes[i].Path != p.Routes[j].Path {
+			return p.Routes[i].Path < p.Routes[j].Path
+		}
+		return p.Routes[i].Method < p.Routes[j].Method
+	})
+	return p
+}
+
+func fromFields(in []ir.IRModelField) []Field {
+	var out []Field
+	for _, f := range in {
+		out = append(out, Field{
+			Name:        f.Name,
+			Type:        f.Type,
+			Required:    f.Required,
+			Unique:      f.Unique,
+			Description: f.Description,
+			Fields:      fromFields(f.Fields),
+		})
+	}
+	return out
+}